@@ -1,39 +1,111 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"embed"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 )
 
 const (
-	// Template paths.
-	validatorTplPath = "./handlers_gen/templates/api_validator.tmpl"
-	handlerTplPath   = "./handlers_gen/templates/func_handler.tmpl"
+	// Template paths within embedded templates.
+	validatorTplPath = "templates/api_validator.tmpl"
+	handlerTplPath   = "templates/func_handler.tmpl"
+	runtimeTplPath   = "templates/api_runtime.tmpl"
+	docsPagePath     = "templates/docs_page.html"
+	clientTplPath    = "templates/api_client.tmpl"
+	tsClientTplPath  = "templates/api_client_ts.tmpl"
+	testsTplPath     = "templates/api_handlers_test.tmpl"
+	fuzzTplPath      = "templates/api_fuzz_test.tmpl"
+	// Header of every file generator writes.
+	generatedMarker = "warning: Automatically generated. Do not edit"
 	// Codegen annotations.
 	apiGenAnnotation       = "apigen:api"
 	apiValidatorAnnotation = "apivalidator:"
+	apiValidatorTag        = "apivalidator"
 	// Paramname validators
 	paramNameValidator    = "paramname"
 	defaultValueValidator = "default"
 	minValueValidator     = "min"
 	maxValueValidator     = "max"
-	enumValuesValidator   = "enum"
+	gtValueValidator      = "gt"
+	ltValueValidator      = "lt"
+	lenValueValidator     = "len"
+	countBytesValidator   = "bytes"
+	// Normalization directives
+	trimDirective     = "trim"
+	collapseDirective = "collapse"
+	lowerDirective    = "lower"
+	upperDirective    = "upper"
+	nfcDirective      = "nfc"
+	// Imports required by generated code on demand.
+	normImport             = "golang.org/x/text/unicode/norm"
+	enumValuesValidator    = "enum"
+	customValidator        = "custom"
+	patternValidator       = "pattern"
+	formatValidator        = "format"
+	requiredIfValidator    = "required_if"
+	oneOfRequiredValidator = "oneof_required"
+	gtFieldValidator       = "gtfield"
+	gteFieldValidator      = "gtefield"
+	ltFieldValidator       = "ltfield"
+	lteFieldValidator      = "ltefield"
 	// Types of validated struct fields.
 	StringFieldType FieldType = false
 	IntFieldType    FieldType = true
 )
 
+// Templates are embedded, so generator runs from any directory.
+//
+//go:embed templates
+var templatesFS embed.FS
+
+// Directory to write OpenAPI documents of receivers to. Not generated by default.
+var openapiDir = flag.String("openapi", "", "directory to write OpenAPI 3.1 documents `<Receiver>.openapi.json` to")
+
+// Path prefix of generated docs routes, i.e. `/_docs/`. Not generated by default.
+var docsPath = flag.String("docs", "", "path prefix to serve OpenAPI document and docs page of receiver at, i.e. /_docs/")
+
+// Path to serve metrics of endpoints at, i.e. `/metrics`. Not served by default.
+var metricsPath = flag.String("metrics", "", "path to serve Prometheus metrics of endpoints at, i.e. /metrics")
+
+// File to write typed api clients of receivers to. Not generated by default.
+var clientPath = flag.String("client", "", "file to write typed Go clients `<Receiver>Client` to")
+
+// File to write TypeScript types and clients of receivers to. Not generated by default.
+var tsClientPath = flag.String("ts", "", "file to write TypeScript types and fetch-based clients to")
+
+// File to write generated table-driven tests of handlers to, i.e. `api_handlers_gen_test.go`. Not generated by default.
+var testsPath = flag.String("tests", "", "file to write table-driven tests of handlers derived from annotations to")
+
+// File to write fuzz targets of params extraction to, i.e. `api_fuzz_gen_test.go`. Not generated by default.
+var fuzzPath = flag.String("fuzz", "", "file to write fuzz targets `FuzzExtract<Struct>` of params extraction to")
+
+// Formats of string values supported by validator `format`.
+var knownFormats = map[string]bool{"email": true, "uuid": true, "url": true, "ipv4": true, "hostname": true, "date": true}
+
+// Cross-field comparison validators and operator field value must satisfy.
+var comparisonRules = map[string]string{gtFieldValidator: ">", gteFieldValidator: ">=", ltFieldValidator: "<", lteFieldValidator: "<="}
+
 // Load text template file for code generation. On any problem - panic and prevent execution.
 func loadTemplate(tplPath string) *template.Template {
-	if templ, err := template.ParseFiles(tplPath); err != nil {
+	if templ, err := template.ParseFS(templatesFS, tplPath); err != nil {
 		panic(fmt.Sprintf("failed to parse template [%s]: %s", tplPath, err.Error()))
 	} else {
 		return templ
@@ -48,10 +120,20 @@ func isFuncCodegen(a ast.Decl) (*ApiGen, bool) {
 		for _, comment := range f.Doc.List {
 			if strings.Contains(comment.Text, apiGenAnnotation) { // If functions has no annotation - skip it.
 				if api, err := exctractApiGenAnnotation(f); err != nil {
-					panic(fmt.Sprintf("failed to parse annotation on %s", comment.Text))
+					panic(fmt.Sprintf("failed to parse annotation on %s: %s", comment.Text, err.Error()))
 				} else {
+					if timeout, err := time.ParseDuration(api.Timeout); api.Timeout != "" && (err != nil || timeout <= 0) {
+						panic(fmt.Sprintf("invalid timeout of %s: %q, expected positive duration like `2s`", f.Name.Name, api.Timeout))
+					}
+					if _, err := parseByteSize(api.MaxBody); api.MaxBody != "" && err != nil {
+						panic(fmt.Sprintf("invalid maxBody of %s: %q, expected positive size like `1MB`", f.Name.Name, api.MaxBody))
+					}
+					if !isApiSignature(f) {
+						panic(fmt.Sprintf("annotated %s must be method of struct pointer declared as func(context.Context, Params) (Result, error)", f.Name.Name))
+					}
 					api.Target = f
 					api.ArgType = f.Type.Params.List[1].Type
+					api.ResultType = f.Type.Results.List[0].Type
 					api.receiver = f.Recv.List[0].Type.(*ast.StarExpr).X.(*ast.Ident).Name // name of struct-receiver
 					return api, true
 				}
@@ -61,6 +143,21 @@ func isFuncCodegen(a ast.Decl) (*ApiGen, bool) {
 	}
 }
 
+// Check annotated function is method of struct pointer taking context and params, returning result and error.
+func isApiSignature(f *ast.FuncDecl) bool {
+	if f.Recv == nil || len(f.Recv.List) != 1 {
+		return false
+	}
+	if star, ok := f.Recv.List[0].Type.(*ast.StarExpr); !ok {
+		return false
+	} else if _, ok := star.X.(*ast.Ident); !ok {
+		return false
+	}
+	params, results := f.Type.Params.List, f.Type.Results
+	return len(params) == 2 && len(params[0].Names) <= 1 && len(params[1].Names) <= 1 &&
+		results != nil && len(results.List) == 2 && len(results.List[0].Names) <= 1
+}
+
 // Parse AST node : it must be of type struct and contain any field with codegen marker: 'apivalidator'.
 func isStructCodegen(a ast.Decl) (*StructValidator, bool) {
 	if f, isGen := a.(*ast.GenDecl); !isGen { // `node.Decls` -> `ast.GenDecl`
@@ -94,16 +191,13 @@ func isStructCodegen(a ast.Decl) (*StructValidator, bool) {
 
 // Parse annotated struct field and return aggregated information of it.
 func produceValidator(f *ast.Field) (result *FieldValidator) {
-	tag := f.Tag.Value
-	if len(tag) < 10 {
-		return nil
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		panic("invalid tag of field " + f.Names[0].Name + ": " + f.Tag.Value)
 	}
-	// Purify string content for tokenizing annotation values.
+	// Extract annotation values for tokenizing.
 	// i.e:  apivalidator:"enum=user|moderator|admin,default=user" -> enum=user|moderator|admin,default=user
-	tag = strings.ReplaceAll(tag, apiValidatorAnnotation, "")
-	tag = strings.ReplaceAll(tag, "\"", "")
-	tag = strings.ReplaceAll(tag, "`", "")
-	validators := strings.Split(tag, ",")
+	validators := splitValidators(f.Names[0].Name, reflect.StructTag(tag).Get(apiValidatorTag))
 	// Prepare result
 	result = &FieldValidator{FieldType: f.Type.(*ast.Ident).Name == "int", ParamName: strings.ToLower(f.Names[0].Name)}
 
@@ -113,26 +207,82 @@ func produceValidator(f *ast.Field) (result *FieldValidator) {
 			result.Required = true
 			continue
 		}
-		kv := strings.Split(v, "=") // Expecting annotation value presented. Otherwise panicked.
+		if !strings.Contains(v, "=") && v == countBytesValidator {
+			result.CountBytes = true
+			continue
+		}
+		if !strings.Contains(v, "=") && isNormalizationDirective(v) {
+			if result.FieldType == IntFieldType {
+				panic("directive `" + v + "` is not applicable to int field " + f.Names[0].Name)
+			}
+			switch v {
+			case trimDirective:
+				result.Trim = true
+			case collapseDirective:
+				result.Collapse = true
+			case lowerDirective:
+				result.Lower = true
+			case upperDirective:
+				result.Upper = true
+			case nfcDirective:
+				result.NFC = true
+			}
+			if result.Lower && result.Upper {
+				panic("directives `lower` and `upper` are mutually exclusive on field " + f.Names[0].Name)
+			}
+			continue
+		}
+		kv := strings.SplitN(v, "=", 2) // Expecting annotation value presented. Otherwise panicked.
 		switch kv[0] {
 		case paramNameValidator:
 			result.ParamName = kv[1]
 		case defaultValueValidator:
 			result.Default = kv[1]
 		case minValueValidator:
-			if intVal, err := strconv.Atoi(kv[1]); err != nil {
-				panic("invalid int value for validator `min`:" + kv[1])
-			} else {
-				result.Min = intVal
-			}
+			result.Min = parseBound(kv[0], kv[1])
 		case maxValueValidator:
-			if intVal, err := strconv.Atoi(kv[1]); err != nil {
-				panic("invalid int value for validator `max`:" + kv[1])
-			} else {
-				result.Max = intVal
+			result.Max = parseBound(kv[0], kv[1])
+		case gtValueValidator:
+			result.Gt = parseBound(kv[0], kv[1])
+		case ltValueValidator:
+			result.Lt = parseBound(kv[0], kv[1])
+		case lenValueValidator:
+			if result.FieldType == IntFieldType {
+				panic("validator `len` is not applicable to int field " + f.Names[0].Name)
 			}
+			result.Len = parseBound(kv[0], kv[1])
 		case enumValuesValidator:
 			result.Enum = strings.Split(kv[1], "|")
+		case patternValidator:
+			if result.FieldType == IntFieldType {
+				panic("validator `pattern` is not applicable to int field " + f.Names[0].Name)
+			}
+			if _, err := regexp.Compile(kv[1]); err != nil {
+				panic("invalid regexp for validator `pattern`: " + err.Error())
+			}
+			result.Pattern = kv[1]
+		case formatValidator:
+			if result.FieldType == IntFieldType {
+				panic("validator `format` is not applicable to int field " + f.Names[0].Name)
+			}
+			if !knownFormats[kv[1]] {
+				panic("unknown format for validator `format`: " + kv[1])
+			}
+			result.Format = kv[1]
+		case requiredIfValidator: // required_if=Status admin
+			condition := strings.SplitN(kv[1], " ", 2)
+			if len(condition) != 2 {
+				panic("expected `Field value` for validator `required_if`: " + kv[1])
+			}
+			result.Relations = append(result.Relations, CrossFieldRule{Kind: kv[0], Fields: condition[:1], Value: condition[1]})
+		case oneOfRequiredValidator: // oneof_required=Email|Phone
+			result.Relations = append(result.Relations, CrossFieldRule{Kind: kv[0], Fields: strings.Split(kv[1], "|")})
+		case gtFieldValidator, gteFieldValidator, ltFieldValidator, lteFieldValidator: // gtfield=Since
+			result.Relations = append(result.Relations, CrossFieldRule{Kind: kv[0], Fields: []string{kv[1]}})
+		case customValidator:
+			for _, name := range strings.Split(kv[1], "|") {
+				result.Custom = append(result.Custom, CustomValidator{Name: name})
+			}
 		default:
 			panic("unknown validator : " + kv[0]) // Prevent futher exection - wrong annotation detected.
 		}
@@ -140,30 +290,346 @@ func produceValidator(f *ast.Field) (result *FieldValidator) {
 	return
 }
 
-func parseSourceFile() (funcsForCodegen map[StructReceiver]Methods, structsForCodegen []*StructValidator) {
+// Check name is of validator : of one taking value, i.e. `min` of `min=1`, or of flag, i.e. `required`.
+func isValidatorName(name string, withValue bool) bool {
+	if !withValue {
+		return name == "required" || name == countBytesValidator || isNormalizationDirective(name)
+	}
+	switch name {
+	case paramNameValidator, defaultValueValidator, minValueValidator, maxValueValidator, gtValueValidator, ltValueValidator,
+		lenValueValidator, enumValuesValidator, customValidator, patternValidator, formatValidator, requiredIfValidator,
+		oneOfRequiredValidator, gtFieldValidator, gteFieldValidator, ltFieldValidator, lteFieldValidator:
+		return true
+	}
+	return false
+}
+
+func isNormalizationDirective(v string) bool {
+	switch v {
+	case trimDirective, collapseDirective, lowerDirective, upperDirective, nfcDirective:
+		return true
+	}
+	return false
+}
+
+// Parse int value of bound validator : min, max, gt, lt, len.
+func parseBound(validator, value string) *int {
+	if intVal, err := strconv.Atoi(value); err != nil {
+		panic("invalid int value for validator `" + validator + "`:" + value)
+	} else {
+		return &intVal
+	}
+}
+
+// Split annotation into separate validators. Value of `pattern` may contain commas,
+// so it takes the rest of annotation and must be the last one : `required,pattern=^[a-z]{1,3}$`.
+// Validator following `pattern` would silently become part of regexp, so it is rejected.
+func splitValidators(fieldName, annotation string) (validators []string) {
+	var pattern string
+	if i := strings.Index(annotation, patternValidator+"="); i == 0 || i > 0 && annotation[i-1] == ',' {
+		annotation, pattern = strings.TrimSuffix(annotation[:i], ","), annotation[i:]
+		for _, part := range strings.Split(pattern, ",")[1:] {
+			if name := strings.SplitN(part, "=", 2)[0]; isValidatorName(name, strings.Contains(part, "=")) {
+				panic(fmt.Sprintf("validator `pattern` of field %s must be the last one : its value takes the rest of annotation, "+
+					"but `%s` follows it", fieldName, part))
+			}
+		}
+	}
+	if annotation != "" {
+		validators = strings.Split(annotation, ",")
+	}
+	if pattern != "" {
+		validators = append(validators, pattern)
+	}
+	return
+}
+
+// Parse source file and collect annotated methods and validated structs. On any problem in source - panic
+// with position of declaration it is found in.
+func parseSourceFile(path string) *SourceFile {
 	// Collect metadata of target functions and structs to be used for code generating.
-	funcsForCodegen = make(map[StructReceiver]Methods, 50)
-	structsForCodegen = make([]*StructValidator, 0, 50)
+	funcsForCodegen := make(map[StructReceiver]Methods, 50)
+	structsForCodegen := make([]*StructValidator, 0, 50)
+	structsPos := make(map[*StructValidator]token.Pos, 50)
 	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, os.Args[1], nil, parser.ParseComments)
+	node, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
 
 	if err != nil {
-		log.Fatal(err)
+		panic(err.Error())
 	}
 
 	var structReceiver StructReceiver
+	declaredFuncs := make(map[string]*ast.FuncDecl, len(node.Decls))
+	declaredTypes := make(map[string]*ast.StructType, len(node.Decls))
+	for _, file := range parsePackageFiles(fset, path, node.Name.Name) { // Declarations of the rest of package.
+		for _, f := range file.Decls {
+			if fn, ok := f.(*ast.FuncDecl); ok {
+				declaredFuncs[funcKey(fn)] = fn
+			}
+			collectStructTypes(f, declaredTypes)
+		}
+	}
 	for _, f := range node.Decls {
-		if fn, ok := isFuncCodegen(f); ok { // Parse source code file for annotated functions and collect info.
-			structReceiver = fn.receiver // use as key a name of struct - receiver of the method
-			funcsForCodegen[structReceiver] = append(funcsForCodegen[structReceiver], fn)
+		func() {
+			defer atPosition(fset, f.Pos())
+			if fn, ok := f.(*ast.FuncDecl); ok { // Collect every function to resolve custom validators.
+				declaredFuncs[funcKey(fn)] = fn
+			}
+			collectStructTypes(f, declaredTypes)
+			if fn, ok := isFuncCodegen(f); ok { // Parse source code file for annotated functions and collect info.
+				structReceiver = fn.receiver // use as key a name of struct - receiver of the method
+				funcsForCodegen[structReceiver] = append(funcsForCodegen[structReceiver], fn)
+			}
+			if st, ok := isStructCodegen(f); ok { // Parse source code file for annotated structs and collect info.
+				structsForCodegen = append(structsForCodegen, st)
+				structsPos[st] = f.Pos()
+			}
+		}()
+	}
+	checkServedPaths()
+	routes := servedRoutes()
+	for _, methods := range funcsForCodegen {
+		for _, api := range methods {
+			func() {
+				defer atPosition(fset, api.Target.Pos())
+				resolveMiddlewares(api, declaredFuncs)
+				checkRouteCollision(api, routes)
+			}()
+		}
+	}
+	for _, st := range structsForCodegen {
+		func() {
+			defer atPosition(fset, structsPos[st])
+			resolveCustomValidators(st, declaredFuncs)
+			checkCrossFieldRules(st)
+			checkRequiredModules(st, filepath.Dir(path))
+		}()
+	}
+	return &SourceFile{Package: node.Name.Name, Methods: funcsForCodegen, Validated: structsForCodegen, Types: declaredTypes, Funcs: declaredFuncs}
+}
+
+// Parse other files of package of source file, so functions and types declared in them are resolved too.
+// Tests, generated files ( i.e. handlers and clients written by previous run ) and files of other packages
+// in the same directory are not part of package.
+func parsePackageFiles(fset *token.FileSet, path, pkgName string) (files []*ast.File) {
+	paths, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*.go"))
+	if err != nil {
+		panic(err.Error())
+	}
+	for _, other := range paths {
+		if filepath.Clean(other) == filepath.Clean(path) || strings.HasSuffix(other, "_test.go") {
+			continue
+		}
+		clause, err := parser.ParseFile(token.NewFileSet(), other, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || clause.Name.Name != pkgName {
+			continue // not a source of package, i.e. tool with `package main` next to it
 		}
-		if st, ok := isStructCodegen(f); ok { // Parse source code file for annotated structs and collect info.
-			structsForCodegen = append(structsForCodegen, st)
+		if isGeneratedFile(clause) {
+			continue
+		}
+		file, err := parser.ParseFile(fset, other, nil, parser.SkipObjectResolution)
+		if err != nil {
+			panic(err.Error())
 		}
+		files = append(files, file)
 	}
 	return
 }
 
+// File written by this generator or by any other one following `// Code generated ... DO NOT EDIT.` convention.
+func isGeneratedFile(clause *ast.File) bool {
+	for _, group := range clause.Comments {
+		if group.Pos() < clause.Package && strings.Contains(group.Text(), generatedMarker) {
+			return true
+		}
+	}
+	return ast.IsGenerated(clause)
+}
+
+// Attach position of declaration to panic raised while parsing it, so diagnostic points to source.
+func atPosition(fset *token.FileSet, pos token.Pos) {
+	if r := recover(); r != nil {
+		panic(fmt.Sprintf("%s: %v", fset.Position(pos), r))
+	}
+}
+
+// Collect every struct type declared by AST node.
+func collectStructTypes(a ast.Decl, types map[string]*ast.StructType) {
+	if f, isGen := a.(*ast.GenDecl); isGen {
+		for _, spec := range f.Specs {
+			if currType, ok := spec.(*ast.TypeSpec); ok {
+				if currStruct, ok := currType.Type.(*ast.StructType); ok {
+					types[currType.Name.Name] = currStruct
+				}
+			}
+		}
+	}
+}
+
+// Key of function in collection of declared ones: `Name` for functions or `Struct.Name` for methods.
+func funcKey(f *ast.FuncDecl) string {
+	if f.Recv == nil || len(f.Recv.List) == 0 {
+		return f.Name.Name
+	}
+	recv := f.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + f.Name.Name
+	}
+	return f.Name.Name
+}
+
+// Resolve custom validators of struct fields against declared functions and check their signature.
+// Method of params struct takes precedence over plain function with the same name.
+func resolveCustomValidators(st *StructValidator, funcs map[string]*ast.FuncDecl) {
+	for fieldName, validator := range st.Validators {
+		for i := range validator.Custom {
+			custom := &validator.Custom[i]
+			fn, isMethod := funcs[st.StructName+"."+custom.Name]
+			if !isMethod {
+				fn = funcs[custom.Name]
+			}
+			if fn == nil || !isValidatorSignature(fn, validator.GoType()) {
+				panic(fmt.Sprintf("custom validator `%s` of %s.%s must be declared as func(%s) error",
+					custom.Name, st.StructName, fieldName, validator.GoType()))
+			}
+			custom.IsMethod = isMethod
+		}
+	}
+}
+
+// Check fields related by cross-field rules are validated ones of the same struct and of suitable type.
+func checkCrossFieldRules(st *StructValidator) {
+	for fieldName, validator := range st.Validators {
+		for _, rule := range validator.Relations {
+			for _, related := range rule.Fields {
+				relatedValidator, ok := st.Validators[related]
+				if !ok {
+					panic(fmt.Sprintf("validator `%s` of %s.%s refers to unknown or not validated field %s",
+						rule.Kind, st.StructName, fieldName, related))
+				}
+				if rule.IsComparison() && relatedValidator.FieldType != validator.FieldType {
+					panic(fmt.Sprintf("validator `%s` of %s.%s compares fields of different types",
+						rule.Kind, st.StructName, fieldName))
+				}
+				if rule.Kind == requiredIfValidator && relatedValidator.FieldType == IntFieldType {
+					if _, err := strconv.Atoi(rule.Value); err != nil {
+						panic(fmt.Sprintf("validator `%s` of %s.%s expects int value of %s: %s",
+							rule.Kind, st.StructName, fieldName, related, rule.Value))
+					}
+				}
+			}
+		}
+	}
+}
+
+// Check modules providing packages imported by validators of struct are required by go.mod of source,
+// otherwise generated code is not built.
+func checkRequiredModules(st *StructValidator, dir string) {
+	for fieldName, validator := range st.Validators {
+		if validator.NFC && !requiresModule(dir, normImport) {
+			panic(fmt.Sprintf("directive `nfc` of %s.%s requires module golang.org/x/text : add it to go.mod of source with `go get golang.org/x/text`",
+				st.StructName, fieldName))
+		}
+	}
+}
+
+// Check go.mod nearest to directory requires module providing package.
+func requiresModule(dir, pkgPath string) bool {
+	for {
+		file, err := os.Open(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "require"))
+				if len(fields) > 1 && (pkgPath == fields[0] || strings.HasPrefix(pkgPath, fields[0]+"/")) {
+					return true
+				}
+			}
+			return false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// Paths given by flags are pasted into routes of router as is, so they must be well-formed.
+func checkServedPaths() {
+	if *docsPath != "" && (!strings.HasPrefix(*docsPath, "/") || !strings.HasSuffix(*docsPath, "/")) {
+		panic(fmt.Sprintf("invalid path of -docs %q, expected prefix starting and ending with `/` like /_docs/", *docsPath))
+	}
+	if *metricsPath != "" && !strings.HasPrefix(*metricsPath, "/") {
+		panic(fmt.Sprintf("invalid path of -metrics %q, expected path starting with `/` like /metrics", *metricsPath))
+	}
+}
+
+// Routes router of every receiver serves in addition to annotated methods : description of route by its path.
+func servedRoutes() map[string]string {
+	routes := make(map[string]string)
+	if *docsPath != "" {
+		routes[*docsPath] = "docs page served by -docs " + *docsPath
+		routes[*docsPath+"openapi.json"] = "OpenAPI document served by -docs " + *docsPath
+	}
+	if *metricsPath != "" {
+		if route, ok := routes[*metricsPath]; ok {
+			panic(fmt.Sprintf("path %s of -metrics collides with %s", *metricsPath, route))
+		}
+		routes[*metricsPath] = "metrics served by -metrics " + *metricsPath
+	}
+	return routes
+}
+
+// Url of method must not be one of routes served by router otherwise, they would be duplicate cases of its switch.
+func checkRouteCollision(api *ApiGen, routes map[string]string) {
+	if route, ok := routes[api.Url]; ok {
+		panic(fmt.Sprintf("url %s of %s.%s collides with %s", api.Url, api.receiver, api.Target.Name.Name, route))
+	}
+}
+
+// Resolve middlewares of method against methods of its receiver. Unresolved ones are looked up
+// among registered functions at runtime.
+func resolveMiddlewares(api *ApiGen, funcs map[string]*ast.FuncDecl) {
+	for i := range api.Middleware {
+		mw := &api.Middleware[i]
+		if mw.Name == "" {
+			panic(fmt.Sprintf("empty middleware name of %s.%s", api.receiver, api.Target.Name.Name))
+		}
+		if fn, isMethod := funcs[api.receiver+"."+mw.Name]; isMethod {
+			if !isMiddlewareSignature(fn) {
+				panic(fmt.Sprintf("middleware `%s` of %s.%s must be declared as func(http.Handler) http.Handler",
+					mw.Name, api.receiver, api.Target.Name.Name))
+			}
+			mw.IsMethod = true
+		}
+	}
+}
+
+// Check function signature is `func(http.Handler) http.Handler`.
+func isMiddlewareSignature(fn *ast.FuncDecl) bool {
+	params, results := fn.Type.Params.List, fn.Type.Results
+	if len(params) != 1 || len(params[0].Names) > 1 || results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+		return false
+	}
+	return types.ExprString(params[0].Type) == "http.Handler" && types.ExprString(results.List[0].Type) == "http.Handler"
+}
+
+// Check function signature is `func(<argType>) error`.
+func isValidatorSignature(fn *ast.FuncDecl, argType string) bool {
+	params, results := fn.Type.Params.List, fn.Type.Results
+	if len(params) != 1 || len(params[0].Names) > 1 || results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+		return false
+	}
+	arg, isIdent := params[0].Type.(*ast.Ident)
+	res, isResIdent := results.List[0].Type.(*ast.Ident)
+	return isIdent && isResIdent && arg.Name == argType && res.Name == "error"
+}
+
 // Extract apigen annotation for detected struct.
 // i.e. apigen:api {"url": "/user/create", "auth": true, "method": "POST"} -> {Url: /user/create, Auth: false, Method: POST}
 func exctractApiGenAnnotation(f *ast.FuncDecl) (api *ApiGen, err error) {
@@ -173,29 +639,109 @@ func exctractApiGenAnnotation(f *ast.FuncDecl) (api *ApiGen, err error) {
 	return
 }
 
+// Collect imports required by validators of structs in addition to common ones.
+func requiredImports(structs []*StructValidator) (imports []string) {
+	for _, st := range structs {
+		for _, validator := range st.Validators {
+			if validator.NFC {
+				return append(imports, normImport)
+			}
+		}
+	}
+	return
+}
+
 // Genereate required code wrappers for detected funcions.
-func handleFuncsCodegen(funcs map[StructReceiver][]*ApiGen, out *os.File, templ *template.Template) {
-	if err := templ.Execute(out, funcs); err != nil {
+func handleFuncsCodegen(src *SourceFile, out io.Writer, templ *template.Template) {
+	data := &HandlersCodegen{Package: src.Package, Receivers: src.Methods, Imports: requiredImports(src.Validated), MetricsPath: *metricsPath}
+	if *docsPath != "" { // Embed OpenAPI document and docs page to be served by router.
+		page, err := templatesFS.ReadFile(docsPagePath)
+		if err != nil || strings.Contains(string(page), "`") { // page is embedded as raw string literal
+			panic(fmt.Sprintf("failed to read docs page [%s]: %v", docsPagePath, err))
+		}
+		data.DocsPath, data.DocsPage, data.Docs = *docsPath, string(page), make(map[StructReceiver]string, len(src.Methods))
+		for receiver, methods := range src.Methods {
+			doc, err := json.Marshal(buildOpenApi(src, receiver, methods))
+			if err != nil {
+				panic(fmt.Errorf("failed to encode OpenAPI document of %s: %s", receiver, err.Error()))
+			}
+			data.Docs[receiver] = string(doc)
+		}
+	}
+	if err := templ.Execute(out, data); err != nil {
 		panic(fmt.Errorf("failed to process template [%s]: %s", templ.Name(), err.Error()))
 	}
 }
 
 // Genereate required validation code wrappers for detected structs.
-func handleStructsCodegen(structs []*StructValidator, out *os.File, templ *template.Template) {
+func handleStructsCodegen(structs []*StructValidator, out io.Writer, templ *template.Template) {
 	if err := templ.Execute(out, structs); err != nil {
 		panic(fmt.Errorf("failed to process template [%s]: %s", templ.Name(), err.Error()))
 	}
 }
 
+// Genereate runtime support code shared by all handlers.
+func handleRuntimeCodegen(out io.Writer, templ *template.Template) {
+	if err := templ.Execute(out, nil); err != nil {
+		panic(fmt.Errorf("failed to process template [%s]: %s", templ.Name(), err.Error()))
+	}
+}
+
+// Genereate typed clients of receivers into separate file of the same package.
+func handleClientCodegen(src *SourceFile, path string, templ *template.Template) {
+	out, err := os.Create(path)
+	if err != nil {
+		panic(fmt.Errorf("failed to create client file [%s]: %s", path, err.Error()))
+	}
+	defer out.Close()
+	if err := templ.Execute(out, src); err != nil {
+		panic(fmt.Errorf("failed to process template [%s]: %s", templ.Name(), err.Error()))
+	}
+}
+
+// Generate http-handlers and validators of source file. Any problem found in source or templates
+// is returned as error, nothing is written in this case.
+func generateHandlers(path string, out io.Writer) (src *SourceFile, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			src, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+	src = parseSourceFile(path)
+	var generated bytes.Buffer
+	handleFuncsCodegen(src, &generated, loadTemplate(handlerTplPath))               // Generate http-handlers wrappers for functions.
+	handleStructsCodegen(src.Validated, &generated, loadTemplate(validatorTplPath)) // Generate validators wrappers for structs.
+	_, err = generated.WriteTo(out)
+	return
+}
+
 func main() {
 	// Load templates content. Fail with error immediately on any problem.
-	handlerTemplate := loadTemplate(handlerTplPath)
-	validatorTemplate := loadTemplate(validatorTplPath)
+	runtimeTemplate := loadTemplate(runtimeTplPath)
+	flag.Parse()
 	// Parse source code file which we need to generate wrappers.
-	funcsForCodegen, structsForCodegen := parseSourceFile()
-	out, _ := os.Create(os.Args[2])
-	defer out.Close()
-
-	handleFuncsCodegen(funcsForCodegen, out, handlerTemplate)       // Generate http-handlers wrappers for functions.
-	handleStructsCodegen(structsForCodegen, out, validatorTemplate) // Generate validators wrappers for structs.
+	var out bytes.Buffer
+	src, err := generateHandlers(flag.Arg(0), &out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handleRuntimeCodegen(&out, runtimeTemplate) // Generate runtime support code.
+	if err := os.WriteFile(flag.Arg(1), out.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if *clientPath != "" {
+		handleClientCodegen(src, *clientPath, loadTemplate(clientTplPath)) // Generate typed clients of receivers.
+	}
+	if *tsClientPath != "" {
+		handleTypeScriptCodegen(src, *tsClientPath, loadTemplate(tsClientTplPath)) // Generate TypeScript clients.
+	}
+	if *testsPath != "" {
+		handleTestsCodegen(src, *testsPath, loadTemplate(testsTplPath)) // Generate boundary-value tests of handlers.
+	}
+	if *fuzzPath != "" {
+		handleFuzzCodegen(src, *fuzzPath, loadTemplate(fuzzTplPath)) // Generate fuzz targets of params extraction.
+	}
+	if *openapiDir != "" {
+		handleOpenApiCodegen(src, *openapiDir) // Generate OpenAPI documents of receivers.
+	}
 }
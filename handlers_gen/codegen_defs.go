@@ -1,8 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"go/ast"
+	"go/types"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type (
@@ -14,34 +22,194 @@ type (
 
 // Struct to aggregate infromation about method found for codegen appliance
 type ApiGen struct {
-	Url      string        `json:"url"`
-	Auth     bool          `json:"auth"`
-	Method   string        `json:"method"`
-	Target   *ast.FuncDecl // target function for http-wrapper codegen
-	receiver string        // name to struct as method receiver ( used as key in map)
-	ArgType  ast.Expr
+	Url           string          `json:"url"`
+	Auth          AuthSpec        `json:"auth"`
+	Method        string          `json:"method"`
+	CollectErrors bool            `json:"collectErrors"` // respond with every failed param instead of the first one
+	Middleware    []MiddlewareRef `json:"middleware"`    // middlewares wrapping handler of method, the first one is the outermost
+	Timeout       string          `json:"timeout"`       // timeout of method call, i.e. `2s`. empty - not bounded
+	MaxBody       string          `json:"maxBody"`       // limit of request body size, i.e. `1MB`. empty - default limit of runtime
+	Target        *ast.FuncDecl   // target function for http-wrapper codegen
+	receiver      string          // name to struct as method receiver ( used as key in map)
+	ArgType       ast.Expr
+	ResultType    ast.Expr
+}
+
+// Source code of method timeout, i.e. `time.Duration(2000000000)` for `2s`.
+func (api *ApiGen) TimeoutLiteral() string {
+	timeout, _ := time.ParseDuration(api.Timeout) // checked on parsing of annotation
+	return "time.Duration(" + strconv.FormatInt(int64(timeout), 10) + ")"
+}
+
+// Limit of request body size in bytes, i.e. `1048576` for `1MB`.
+func (api *ApiGen) MaxBodyBytes() int64 {
+	size, _ := parseByteSize(api.MaxBody) // checked on parsing of annotation
+	return size
+}
+
+// Multipliers of size units, the longest suffixes go first.
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+
+// Parse size like `512KB` or `1MB` into bytes. Units are binary, number without unit is in bytes.
+func parseByteSize(size string) (int64, error) {
+	value, multiplier := strings.ToUpper(strings.TrimSpace(size)), int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.multiplier
+			break
+		}
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number <= 0 || number > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return number * multiplier, nil
+}
+
+// Source code of method argument type, i.e. `CreateParams`.
+func (api *ApiGen) ArgTypeName() string {
+	return types.ExprString(api.ArgType)
+}
+
+// Source code of method result type, i.e. `*NewUser`.
+func (api *ApiGen) ResultTypeName() string {
+	return types.ExprString(api.ResultType)
+}
+
+// Middleware of method referenced by name : either method of receiver of signature
+// `func(http.Handler) http.Handler` or function registered with `RegisterMiddleware` at runtime.
+type MiddlewareRef struct {
+	Name     string
+	IsMethod bool // method of receiver
+}
+
+func (m *MiddlewareRef) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &m.Name)
+}
+
+// Authorization requirement of method. Annotated either as plain flag - `"auth": true` (static token in header),
+// or as object with scopes of api key - `"auth": {"apikey": ["users:write"]}`.
+type AuthSpec struct {
+	Token     bool     // static token in header is required
+	UseApiKey bool     // api key is required
+	Scopes    []string // scopes api key must be granted with
+}
+
+func (a *AuthSpec) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Token); err == nil {
+		return nil
+	}
+	var spec struct {
+		ApiKey *[]string `json:"apikey"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+	if spec.ApiKey == nil {
+		return errors.New("auth object must contain `apikey` scopes")
+	}
+	a.UseApiKey, a.Scopes = true, *spec.ApiKey
+	return nil
 }
 
 // Aggregate information on every struct field to apply validation
 type FieldValidator struct {
-	ParamName string    // name of request param . default - field name on lowercase
-	FieldType FieldType // string  \ int
-	Required  bool
-	Enum      []string // allowed predefined values
-	Default   interface{}
-	Min, Max  int // value of int. or lenght of string
+	ParamName  string    // name of request param . default - field name on lowercase
+	FieldType  FieldType // string  \ int
+	Required   bool
+	Enum       []string // allowed predefined values
+	Pattern    string   // regexp value of string must match
+	Format     string   // well-known format of string value : email, uuid, url, ipv4, hostname, date
+	Default    interface{}
+	Min, Max   *int // inclusive bounds of int value or lenght of string
+	Gt, Lt     *int // exclusive bounds of int value or lenght of string
+	Len        *int // exact lenght of string
+	CountBytes bool // lenght of string in bytes instead of runes
+	// Normalization of string value applied before validation.
+	Trim, Collapse, Lower, Upper, NFC bool
+	Custom                            []CustomValidator // user-defined validators of field value
+	Relations                         []CrossFieldRule  // rules relating field to other fields
+}
+
+// Rule relating field to other fields of params struct. Checked after every field is validated on its own.
+type CrossFieldRule struct {
+	Kind   string   // gtfield, gtefield, ltfield, ltefield, required_if, oneof_required
+	Fields []string // names of related struct fields
+	Value  string   // value of related field for `required_if`
+}
+
+// Operator field value must satisfy comparing with related field.
+func (r CrossFieldRule) Operator() string {
+	return comparisonRules[r.Kind]
+}
+
+func (r CrossFieldRule) IsComparison() bool {
+	return comparisonRules[r.Kind] != ""
+}
+
+// User-defined validator referenced as `custom=validLogin` : either method of params struct or plain function,
+// both of signature `func(<field type>) error`.
+type CustomValidator struct {
+	Name     string
+	IsMethod bool // method of params struct
+}
+
+// Literal of zero value of field type.
+func (fv *FieldValidator) ZeroLiteral() string {
+	if fv.FieldType == IntFieldType {
+		return "0"
+	}
+	return `""`
+}
+
+func (fv *FieldValidator) GoType() string {
+	if fv.FieldType == IntFieldType {
+		return "int"
+	}
+	return "string"
 }
 
 func (fv *FieldValidator) HasEnumConstraint() bool {
 	return len(fv.Enum) > 0
 }
 
-func (fv *FieldValidator) HasMinConstraint() bool {
-	return fv.Min >= 0
+// Bound of int value or length of string.
+type Bound struct {
+	Kind     string // min, max, gt, lt, len
+	Operator string // operator value must satisfy comparing with bound
+	Value    int
+}
+
+// Relation of value to bound for error message, i.e. `>= 10`.
+func (b Bound) Relation() string {
+	if b.Operator == "==" {
+		return strconv.Itoa(b.Value)
+	}
+	return b.Operator + " " + strconv.Itoa(b.Value)
 }
 
-func (fv *FieldValidator) HasMaxConstraint() bool {
-	return fv.Max > 0
+// Bounds constraints of field in order of check.
+func (fv *FieldValidator) Bounds() (bounds []Bound) {
+	for _, bound := range []struct {
+		kind, operator string
+		value          *int
+	}{{"len", "==", fv.Len}, {"min", ">=", fv.Min}, {"gt", ">", fv.Gt}, {"max", "<=", fv.Max}, {"lt", "<", fv.Lt}} {
+		if bound.value != nil {
+			bounds = append(bounds, Bound{Kind: bound.kind, Operator: bound.operator, Value: *bound.value})
+		}
+	}
+	return
+}
+
+// Function measuring length of string field.
+func (fv *FieldValidator) LenFunc() string {
+	if fv.CountBytes {
+		return "len"
+	}
+	return "utf8.RuneCountInString"
 }
 
 func (fv *FieldValidator) StringifyEnum() string { // Usefull for generating string slice literal
@@ -52,7 +220,48 @@ func (fv *FieldValidator) HasDefault() bool {
 	return fv.Default != nil
 }
 
+// Everything collected from source file for code generation.
+type SourceFile struct {
+	Package   string                     // package name of source file
+	Methods   map[StructReceiver]Methods // annotated methods by receiver
+	Validated []*StructValidator         // params structs with validators
+	Types     map[string]*ast.StructType // every struct declared in package by name
+	Funcs     map[string]*ast.FuncDecl   // every function declared in package by `Name` or `Struct.Name`
+}
+
+// Validators of params struct by its name.
+func (sf *SourceFile) ValidatorOf(structName string) *StructValidator {
+	for _, st := range sf.Validated {
+		if st.StructName == structName {
+			return st
+		}
+	}
+	return nil
+}
+
+// Data for generating http-handlers : annotated methods by receiver and imports required by generated code
+// in addition to common ones.
+type HandlersCodegen struct {
+	Package     string // package name of source file
+	Receivers   map[StructReceiver]Methods
+	Imports     []string
+	DocsPath    string                    // path prefix of docs routes. empty - docs are not served
+	MetricsPath string                    // path of metrics route. empty - metrics are not served
+	DocsPage    string                    // html of docs page
+	Docs        map[StructReceiver]string // OpenAPI document of receiver
+}
+
 type StructValidator struct {
 	StructName string
 	Validators map[FieldName]*FieldValidator
 }
+
+// Names of validated fields in order of generated checks.
+func (sv *StructValidator) FieldNames() []string {
+	names := make([]string, 0, len(sv.Validators))
+	for name := range sv.Validators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
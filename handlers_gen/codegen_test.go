@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Regenerate golden files instead of comparing with them : go test ./handlers_gen -update
+var update = flag.Bool("update", false, "update golden files of generator tests")
+
+// Stubs of packages outside of standard library generated code may import, by import path.
+const stubsDir = "testdata/stubs"
+
+// Sources of cases may leave out ApiError every package using generated code declares.
+const apiErrorStub = `package main
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string { return ae.Err.Error() }
+`
+
+// Every `testdata/<case>/api.go` is generated ( with other files of its package ) and compared either with
+// `api.golden` output or with `api.err` diagnostic if source is expected to be rejected. Case with `api.flags`
+// is generated with them and with every optional output, each compared with its own golden file
+// `api.<output>.golden`. Generated code is type-checked together with source.
+func TestGenerateGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*", "api.go"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no inputs in testdata: %v", err)
+	}
+	for _, input := range inputs {
+		input := input
+		base := strings.TrimSuffix(input, ".go")
+		t.Run(filepath.Base(filepath.Dir(input)), func(t *testing.T) {
+			withFlags(t, base+".flags")
+			var out bytes.Buffer
+			src, err := generateHandlers(input, &out)
+			goldenPath, stalePath, got := base+".golden", base+".err", out.Bytes()
+			if err != nil {
+				goldenPath, stalePath, got = base+".err", base+".golden", []byte(err.Error()+"\n")
+			}
+			compareGolden(t, goldenPath, got)
+			if _, err := os.Stat(stalePath); err == nil {
+				if !*update {
+					t.Fatalf("expected result in %s, got one for %s", stalePath, goldenPath)
+				}
+				os.Remove(stalePath)
+			}
+			if err != nil {
+				return
+			}
+			generated := map[string][]byte{"api_handlers.go": withRuntime(out.Bytes())}
+			if _, err := os.Stat(base + ".flags"); err == nil {
+				for output, content := range generateOutputs(t, src) {
+					compareGolden(t, base+"."+output+".golden", content)
+					if strings.HasSuffix(output, ".go") {
+						generated[output] = content
+					}
+				}
+			}
+			checkTypes(t, input, generated)
+		})
+	}
+}
+
+// Outputs of previous run next to source are not part of its package : the second run generates the same.
+func TestGenerateTwice(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "api.go")
+	source, err := os.ReadFile(filepath.Join("testdata", "multiple_receivers", "api.go"))
+	if err == nil {
+		err = os.WriteFile(input, source, 0644)
+	}
+	if err != nil {
+		t.Fatalf("failed to copy source: %v", err)
+	}
+	var runs [2]map[string][]byte
+	for i := range runs {
+		var out bytes.Buffer
+		src, err := generateHandlers(input, &out)
+		if err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+		runs[i] = generateOutputs(t, src)
+		runs[i]["api_handlers.go"] = withRuntime(out.Bytes())
+		for name, content := range runs[i] {
+			if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+	}
+	if !bytes.Contains(runs[0]["handlers_test.go"], []byte("Name:")) {
+		t.Fatalf("expected cases of handlers in tests of the first run")
+	}
+	for name, content := range runs[0] {
+		if !bytes.Equal(runs[1][name], content) {
+			t.Errorf("%s of the second run differs:\n%s", name, firstDiff(content, runs[1][name]))
+		}
+	}
+}
+
+// Runtime support code is the same for every source, so it has single golden file.
+func TestGenerateRuntimeGolden(t *testing.T) {
+	var out bytes.Buffer
+	handleRuntimeCodegen(&out, loadTemplate(runtimeTplPath))
+	compareGolden(t, filepath.Join("testdata", "runtime.golden"), out.Bytes())
+}
+
+// Set generator flags listed in file, i.e. `-docs /_docs/`, until the end of test. Missing file - no flags.
+func withFlags(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	args := strings.Fields(string(data))
+	for i := 0; len(args) > i+1; i += 2 {
+		name := strings.TrimLeft(args[i], "-")
+		previous := flag.Lookup(name)
+		if previous == nil {
+			t.Fatalf("unknown flag in %s: %s", path, args[i])
+		}
+		defaultValue := previous.Value.String()
+		if err := flag.Set(name, args[i+1]); err != nil {
+			t.Fatalf("invalid flag in %s: %v", path, err)
+		}
+		t.Cleanup(func() { flag.Set(name, defaultValue) })
+	}
+}
+
+// Generate every optional output of source : clients, tests, fuzz targets and OpenAPI documents, by output name.
+func generateOutputs(t *testing.T, src *SourceFile) map[string][]byte {
+	t.Helper()
+	dir := t.TempDir()
+	handleClientCodegen(src, filepath.Join(dir, "client.go"), loadTemplate(clientTplPath))
+	handleTypeScriptCodegen(src, filepath.Join(dir, "client.ts"), loadTemplate(tsClientTplPath))
+	handleTestsCodegen(src, filepath.Join(dir, "handlers_test.go"), loadTemplate(testsTplPath))
+	handleFuzzCodegen(src, filepath.Join(dir, "fuzz_test.go"), loadTemplate(fuzzTplPath))
+	handleOpenApiCodegen(src, filepath.Join(dir, "openapi"))
+	outputs := make(map[string][]byte)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		outputs[filepath.Base(path)] = content
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to read outputs: %v", err)
+	}
+	return outputs
+}
+
+func withRuntime(handlers []byte) []byte {
+	var source bytes.Buffer
+	source.Write(handlers)
+	handleRuntimeCodegen(&source, loadTemplate(runtimeTplPath))
+	return source.Bytes()
+}
+
+// Generated code must compile together with source : type-check them as single package.
+func checkTypes(t *testing.T, input string, generated map[string][]byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	source, err := parser.ParseFile(fset, input, nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse source: %v", err)
+	}
+	files := append([]*ast.File{source}, parsePackageFiles(fset, input, source.Name.Name)...)
+	if !declaresType(files, "ApiError") {
+		stub, _ := parser.ParseFile(fset, "api_error.go", apiErrorStub, 0)
+		files = append(files, stub)
+	}
+	names := make([]string, 0, len(generated))
+	for name := range generated {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file, err := parser.ParseFile(fset, name, generated[name], 0)
+		if err != nil {
+			t.Fatalf("generated code is not valid Go: %v", err)
+		}
+		files = append(files, file)
+	}
+	var errs []string
+	conf := &types.Config{
+		Importer: &moduleImporter{std: importer.Default(), module: filepath.Dir(input), stubs: make(map[string]*types.Package)},
+		Error:    func(err error) { errs = append(errs, err.Error()) },
+	}
+	conf.Check(source.Name.Name, fset, files, nil)
+	if len(errs) > 0 {
+		t.Fatalf("generated code does not compile:\n%s", strings.Join(errs, "\n"))
+	}
+}
+
+func declaresType(files []*ast.File, name string) bool {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok {
+				for _, spec := range gen.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == name {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Imports packages of standard library and stubs of packages required by go.mod nearest to source.
+type moduleImporter struct {
+	std    types.Importer
+	module string // directory of source
+	stubs  map[string]*types.Package
+}
+
+func (m *moduleImporter) Import(path string) (*types.Package, error) {
+	if !strings.Contains(strings.Split(path, "/")[0], ".") {
+		return m.std.Import(path)
+	}
+	if pkg, ok := m.stubs[path]; ok {
+		return pkg, nil
+	}
+	if !requiresModule(m.module, path) {
+		return nil, fmt.Errorf("package %s is not provided by go.mod of %s", path, m.module)
+	}
+	sources, _ := filepath.Glob(filepath.Join(stubsDir, filepath.FromSlash(path), "*.go"))
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no stub of package %s in %s", path, stubsDir)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, source := range sources {
+		file, err := parser.ParseFile(fset, source, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	pkg, err := (&types.Config{Importer: m}).Check(path, fset, files, nil)
+	m.stubs[path] = pkg
+	return pkg, err
+}
+
+func compareGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated output differs from %s, run with -update and review the diff:\n%s", path, firstDiff(want, got))
+	}
+}
+
+// First differing line of golden and generated outputs.
+func firstDiff(want, got []byte) string {
+	wantLines, gotLines := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"text/template"
+)
+
+// Data for generating fuzz targets of params extraction : every validated struct with seed corpus.
+type FuzzCodegen struct {
+	Package string
+	Targets []*FuzzTarget
+}
+
+type FuzzTarget struct {
+	StructName string
+	Seeds      []string // url encoded params of seed corpus
+}
+
+// Genereate fuzz targets of params extraction into separate file of the same package.
+func handleFuzzCodegen(src *SourceFile, path string, templ *template.Template) {
+	out, err := os.Create(path)
+	if err != nil {
+		panic(fmt.Errorf("failed to create fuzz file [%s]: %s", path, err.Error()))
+	}
+	defer out.Close()
+	if err := templ.Execute(out, buildFuzzTargets(src)); err != nil {
+		panic(fmt.Errorf("failed to process template [%s]: %s", templ.Name(), err.Error()))
+	}
+}
+
+func buildFuzzTargets(src *SourceFile) *FuzzCodegen {
+	result := &FuzzCodegen{Package: src.Package}
+	for _, params := range src.Validated {
+		result.Targets = append(result.Targets, &FuzzTarget{StructName: params.StructName, Seeds: fuzzSeeds(params)})
+	}
+	return result
+}
+
+// Seeds of corpus : every param present with empty value, valid baseline if it is derivable and
+// every invalid case of generated handler tests.
+func fuzzSeeds(params *StructValidator) (seeds []string) {
+	empty := url.Values{}
+	for _, validator := range params.Validators {
+		empty.Set(validator.ParamName, "")
+	}
+	seeds = append(seeds, empty.Encode())
+	baseline, ok := validBaseline(params)
+	if !ok {
+		return
+	}
+	valid := url.Values{}
+	for param, value := range baseline {
+		valid.Set(param, value)
+	}
+	seeds = append(seeds, valid.Encode())
+	for _, fieldName := range params.FieldNames() {
+		validator := params.Validators[fieldName]
+		for _, p := range invalidProbes(validator) {
+			query := url.Values{}
+			for param, value := range baseline {
+				query.Set(param, value)
+			}
+			query.Set(validator.ParamName, p.value)
+			seeds = append(seeds, query.Encode())
+		}
+	}
+	return
+}
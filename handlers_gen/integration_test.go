@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const integrationDir = "testdata/integration"
+
+// Every `testdata/integration/<case>` is a package of annotated source `api.go` ( generated with flags listed in
+// optional `flags` file ) and tests of behavior of its handlers. Handlers and every optional output of cases are
+// generated into packages of temporary module, which is vetted and tested as `make generate test` would do.
+func TestGeneratedBehavior(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and tests temporary module")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool is not available")
+	}
+	cases, err := filepath.Glob(filepath.Join(integrationDir, "*"))
+	if err != nil || len(cases) == 0 {
+		t.Fatalf("no cases in %s: %v", integrationDir, err)
+	}
+	module := t.TempDir()
+	if err := os.WriteFile(filepath.Join(module, "go.mod"), []byte("module integration\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatalf("failed to create module: %v", err)
+	}
+	for _, dir := range cases {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			generatePackage(t, dir, filepath.Join(module, filepath.Base(dir)))
+		})
+	}
+	if t.Failed() {
+		return
+	}
+	for _, args := range [][]string{{"vet", "./..."}, {"test", "-count=1", "./..."}} {
+		cmd := exec.Command(goTool, args...)
+		cmd.Dir = module
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s failed:\n%s", strings.Join(args, " "), out)
+		}
+	}
+}
+
+// Copy files of case into package directory and generate handlers and every optional output next to them.
+func generatePackage(t *testing.T, dir, pkg string) {
+	withFlags(t, filepath.Join(dir, "flags"))
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(pkg, filepath.Base(file)), content, 0644)
+		}
+		if err != nil {
+			t.Fatalf("failed to copy %s: %v", file, err)
+		}
+	}
+	var out bytes.Buffer
+	src, err := generateHandlers(filepath.Join(dir, "api.go"), &out)
+	if err != nil {
+		t.Fatalf("failed to generate handlers: %v", err)
+	}
+	outputs := generateOutputs(t, src)
+	outputs["api_handlers.go"] = withRuntime(out.Bytes())
+	for name, content := range outputs {
+		if strings.HasSuffix(name, ".go") {
+			if err := os.WriteFile(filepath.Join(pkg, name), content, 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	openApiVersion     = "3.1.0"
+	schemasRef         = "#/components/schemas/"
+	errorSchemaName    = "ErrorResponse"
+	problemSchemaName  = "ProblemDetails"
+	formContentType    = "application/x-www-form-urlencoded"
+	jsonContentType    = "application/json"
+	problemContentType = "application/problem+json"
+	// Envelopes of error responses receiver may declare.
+	legacyEnvelope   = "LegacyEnvelope"
+	codedEnvelope    = "CodedEnvelope"
+	problemEnvelope  = "ProblemEnvelope"
+	authTokenScheme  = "AuthToken"
+	apiKeyScheme     = "ApiKey"
+	authTokenHeader  = "X-Auth"
+	apiKeyHeaderName = "X-Api-Key"
+)
+
+// Schemas of OpenAPI `format` for formats of validator `format`.
+var openApiFormats = map[string]string{"email": "email", "uuid": "uuid", "url": "uri", "ipv4": "ipv4", "hostname": "hostname", "date": "date"}
+
+// Schemas of Go builtin types.
+var builtinSchemas = map[string]map[string]interface{}{
+	"string":  {"type": "string"},
+	"bool":    {"type": "boolean"},
+	"int":     {"type": "integer"},
+	"int8":    {"type": "integer", "format": "int32"},
+	"int16":   {"type": "integer", "format": "int32"},
+	"int32":   {"type": "integer", "format": "int32"},
+	"int64":   {"type": "integer", "format": "int64"},
+	"uint":    {"type": "integer", "minimum": 0},
+	"uint8":   {"type": "integer", "minimum": 0},
+	"uint16":  {"type": "integer", "minimum": 0},
+	"uint32":  {"type": "integer", "minimum": 0},
+	"uint64":  {"type": "integer", "format": "int64", "minimum": 0},
+	"float32": {"type": "number", "format": "float"},
+	"float64": {"type": "number", "format": "double"},
+}
+
+// Builds OpenAPI document of single receiver. Schemas of structs are collected into components on demand.
+type openApiBuilder struct {
+	src      *SourceFile
+	schemas  map[string]interface{}
+	envelope string // envelope of error responses. empty - any of them
+}
+
+// Generate OpenAPI documents `<Receiver>.openapi.json` of every receiver. Receivers are served separately
+// and may declare the same urls, so each of them gets own document.
+func handleOpenApiCodegen(src *SourceFile, dir string) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		panic(fmt.Errorf("failed to create OpenAPI directory [%s]: %s", dir, err.Error()))
+	}
+	for receiver, methods := range src.Methods {
+		doc, err := json.MarshalIndent(buildOpenApi(src, receiver, methods), "", "  ")
+		if err != nil {
+			panic(fmt.Errorf("failed to encode OpenAPI document of %s: %s", receiver, err.Error()))
+		}
+		path := filepath.Join(dir, receiver+".openapi.json")
+		if err := os.WriteFile(path, append(doc, '\n'), 0o644); err != nil {
+			panic(fmt.Errorf("failed to write OpenAPI document [%s]: %s", path, err.Error()))
+		}
+	}
+}
+
+// Build OpenAPI document of receiver methods.
+func buildOpenApi(src *SourceFile, receiver StructReceiver, methods Methods) map[string]interface{} {
+	b := &openApiBuilder{src: src, schemas: make(map[string]interface{}), envelope: errorEnvelope(src, receiver)}
+	if b.envelope != problemEnvelope {
+		b.schemas[errorSchemaName] = errorSchema(false)
+	}
+	if b.envelope == problemEnvelope || b.envelope == "" {
+		b.schemas[problemSchemaName] = errorSchema(true)
+	}
+	paths := make(map[string]interface{}, len(methods))
+	securitySchemes := make(map[string]interface{})
+	for _, api := range methods {
+		operations := make(map[string]interface{}, 2)
+		for _, method := range apiHttpMethods(api) {
+			operationId := api.Target.Name.Name
+			if api.Method == "" && method == http.MethodPost {
+				operationId += "Form" // the same method is served both by GET and POST
+			}
+			operations[strings.ToLower(method)] = b.operation(api, method, operationId)
+		}
+		paths[api.Url] = operations
+		if api.Auth.Token {
+			securitySchemes[authTokenScheme] = map[string]interface{}{"type": "apiKey", "in": "header", "name": authTokenHeader}
+		}
+		if api.Auth.UseApiKey {
+			securitySchemes[apiKeyScheme] = map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeaderName}
+		}
+	}
+	components := map[string]interface{}{"schemas": b.schemas}
+	if len(securitySchemes) > 0 {
+		components["securitySchemes"] = securitySchemes
+	}
+	return map[string]interface{}{
+		"openapi":    openApiVersion,
+		"info":       map[string]interface{}{"title": receiver, "version": "1.0.0"},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+// Envelope of error responses of receiver : constant returned by its `ErrorEnvelope() ErrorEnvelope` method.
+// Empty - envelope is chosen at runtime, so any of them may be sent.
+func errorEnvelope(src *SourceFile, receiver StructReceiver) string {
+	fn, ok := src.Funcs[receiver+".ErrorEnvelope"]
+	if !ok {
+		return legacyEnvelope
+	}
+	if fn.Body != nil && len(fn.Body.List) == 1 {
+		if ret, ok := fn.Body.List[0].(*ast.ReturnStmt); ok && len(ret.Results) == 1 {
+			if ident, ok := ret.Results[0].(*ast.Ident); ok {
+				switch ident.Name {
+				case legacyEnvelope, codedEnvelope, problemEnvelope:
+					return ident.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Schema of error response : RFC 7807 problem details or legacy and coded envelopes.
+func errorSchema(problem bool) map[string]interface{} {
+	properties := map[string]interface{}{
+		"code":    map[string]interface{}{"type": "string"},
+		"field":   map[string]interface{}{"type": "string"},
+		"details": map[string]interface{}{},
+		"request_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of request, also sent in `X-Request-ID` header.",
+		},
+		"fields": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		},
+	}
+	if !problem {
+		properties["error"] = map[string]interface{}{"type": "string"}
+		return map[string]interface{}{"type": "object", "required": []string{"error"}, "properties": properties}
+	}
+	properties["type"] = map[string]interface{}{"type": "string"}
+	properties["title"] = map[string]interface{}{"type": "string"}
+	properties["status"] = map[string]interface{}{"type": "integer"}
+	properties["detail"] = map[string]interface{}{"type": "string"}
+	return map[string]interface{}{"type": "object", "required": []string{"type", "title", "status", "detail"}, "properties": properties}
+}
+
+// Http methods serving api : declared one, otherwise both GET and POST.
+func apiHttpMethods(api *ApiGen) []string {
+	if api.Method != "" {
+		return []string{api.Method}
+	}
+	return []string{http.MethodGet, http.MethodPost}
+}
+
+// Describe operation of api served by http method.
+func (b *openApiBuilder) operation(api *ApiGen, method, operationId string) map[string]interface{} {
+	op := map[string]interface{}{"operationId": operationId}
+	if params := b.src.ValidatorOf(typeName(api.ArgType)); params != nil {
+		if method == http.MethodPost {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{formContentType: map[string]interface{}{"schema": paramsSchema(params)}},
+			}
+		} else {
+			op["parameters"] = queryParameters(params)
+		}
+	}
+	errorContent := make(map[string]interface{}, 2)
+	if b.envelope != problemEnvelope {
+		errorContent[jsonContentType] = map[string]interface{}{"schema": ref(errorSchemaName)}
+	}
+	if b.envelope == problemEnvelope || b.envelope == "" {
+		errorContent[problemContentType] = map[string]interface{}{"schema": ref(problemSchemaName)}
+	}
+	errorResponse := func(description string) map[string]interface{} {
+		return map[string]interface{}{"description": description, "content": errorContent}
+	}
+	responses := map[string]interface{}{
+		"200": map[string]interface{}{
+			"description": "successful response",
+			"content": map[string]interface{}{jsonContentType: map[string]interface{}{"schema": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"error", "response"},
+				"properties": map[string]interface{}{
+					"error":    map[string]interface{}{"type": "string", "const": ""},
+					"response": b.typeSchema(api.ResultType),
+				},
+			}}},
+		},
+		"400": errorResponse("invalid params"),
+		"500": errorResponse("internal error"),
+	}
+	if api.Method != "" {
+		responses["406"] = errorResponse("bad method")
+	}
+	responses["413"] = errorResponse("request body or params too large")
+	if api.Method != http.MethodGet { // form body is read only from POST requests
+		responses["408"] = errorResponse("request body not read in time")
+	}
+	if api.Timeout != "" {
+		responses["504"] = errorResponse("method timed out")
+	}
+	var security []interface{}
+	if api.Auth.Token {
+		responses["403"] = errorResponse("unauthorized")
+		security = append(security, map[string]interface{}{authTokenScheme: []string{}})
+	}
+	if api.Auth.UseApiKey {
+		responses["403"] = errorResponse("unauthorized or insufficient scope")
+		responses["429"] = errorResponse("rate limit exceeded")
+		scopes := api.Auth.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		security = append(security, map[string]interface{}{apiKeyScheme: scopes})
+	}
+	if security != nil {
+		op["security"] = security
+	}
+	op["responses"] = responses
+	return op
+}
+
+// Describe params as query parameters, sorted by name like in generated validators.
+func queryParameters(params *StructValidator) []interface{} {
+	var parameters []interface{}
+	for _, fieldName := range params.FieldNames() {
+		validator := params.Validators[fieldName]
+		parameters = append(parameters, map[string]interface{}{
+			"name":     validator.ParamName,
+			"in":       "query",
+			"required": isRequiredParam(validator),
+			"schema":   fieldSchema(validator),
+		})
+	}
+	return parameters
+}
+
+// Describe params as form object.
+func paramsSchema(params *StructValidator) map[string]interface{} {
+	properties := make(map[string]interface{}, len(params.Validators))
+	required := []string{}
+	for _, fieldName := range params.FieldNames() {
+		validator := params.Validators[fieldName]
+		properties[validator.ParamName] = fieldSchema(validator)
+		if isRequiredParam(validator) {
+			required = append(required, validator.ParamName)
+		}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+}
+
+// Tightest of lower and of upper bounds of field, every one of them is checked. Nil - unbounded.
+func tightestBounds(validator *FieldValidator) (lower, upper *Bound) {
+	for _, bound := range validator.Bounds() {
+		if bound.Kind != "max" && bound.Kind != "lt" && (lower == nil || inclusiveBound(bound) > inclusiveBound(*lower)) {
+			lower = &bound
+		}
+		if bound.Kind != "min" && bound.Kind != "gt" && (upper == nil || inclusiveBound(bound) < inclusiveBound(*upper)) {
+			upper = &bound
+		}
+	}
+	return
+}
+
+// Value of bound including it : strict bounds of ints are off by one.
+func inclusiveBound(bound Bound) int {
+	switch bound.Kind {
+	case "gt":
+		return bound.Value + 1
+	case "lt":
+		return bound.Value - 1
+	}
+	return bound.Value
+}
+
+// Param is required if annotated so or if it is int : missing int param fails parsing.
+func isRequiredParam(validator *FieldValidator) bool {
+	return validator.Required || validator.FieldType == IntFieldType
+}
+
+// Describe validated field with its constraints.
+func fieldSchema(validator *FieldValidator) map[string]interface{} {
+	schema := make(map[string]interface{})
+	if validator.FieldType == IntFieldType {
+		schema["type"] = "integer"
+		lower, upper := tightestBounds(validator)
+		if lower != nil {
+			schema[map[string]string{"len": "minimum", "min": "minimum", "gt": "exclusiveMinimum"}[lower.Kind]] = lower.Value
+		}
+		if upper != nil {
+			schema[map[string]string{"len": "maximum", "max": "maximum", "lt": "exclusiveMaximum"}[upper.Kind]] = upper.Value
+		}
+		if validator.HasDefault() {
+			if value, err := strconv.Atoi(fmt.Sprint(validator.Default)); err == nil {
+				schema["default"] = value
+			}
+		}
+	} else {
+		schema["type"] = "string"
+		lower, upper := tightestBounds(validator)
+		if lower != nil {
+			schema["minLength"] = max(inclusiveBound(*lower), 0)
+		}
+		if upper != nil {
+			schema["maxLength"] = max(inclusiveBound(*upper), 0)
+		}
+		if validator.HasEnumConstraint() {
+			schema["enum"] = validator.Enum
+		}
+		if validator.Pattern != "" {
+			schema["pattern"] = validator.Pattern
+		}
+		if validator.Format != "" {
+			schema["format"] = openApiFormats[validator.Format]
+		}
+		if validator.HasDefault() {
+			schema["default"] = fmt.Sprint(validator.Default)
+		}
+	}
+	if validator.Required && validator.FieldType != IntFieldType {
+		if _, ok := schema["minLength"]; !ok {
+			schema["minLength"] = 1
+		}
+	}
+	return schema
+}
+
+// Describe Go type. Structs declared in source file are referenced from components.
+func (b *openApiBuilder) typeSchema(expr ast.Expr) map[string]interface{} {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return b.typeSchema(t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.typeSchema(t.Elt)}
+	case *ast.MapType:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.typeSchema(t.Value)}
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "time" && t.Sel.Name == "Time" {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+	case *ast.Ident:
+		if schema, ok := builtinSchemas[t.Name]; ok {
+			return schema
+		}
+		if st, ok := b.src.Types[t.Name]; ok {
+			if _, collected := b.schemas[t.Name]; !collected {
+				b.schemas[t.Name] = nil // mark as collected before fields, so recursive types are referenced
+				b.schemas[t.Name] = b.structSchema(st)
+			}
+			return ref(t.Name)
+		}
+	}
+	return map[string]interface{}{} // any value
+}
+
+// Describe struct by `json` tags of its fields.
+func (b *openApiBuilder) structSchema(st *ast.StructType) map[string]interface{} {
+	properties := make(map[string]interface{}, len(st.Fields.List))
+	required := []string{}
+	for _, field := range jsonFields(b.src.Types, st) {
+		properties[field.Name] = b.typeSchema(field.Type)
+		if !field.OmitEmpty {
+			required = append(required, field.Name)
+		}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+}
+
+// Field of struct in JSON.
+type jsonField struct {
+	Name      string
+	Type      ast.Expr
+	OmitEmpty bool
+	inlined   bool
+}
+
+// Fields of struct in JSON by their `json` tags. Like encoding/json does, fields of embedded structs declared
+// in package are inlined unless tag names them, field of outer struct hides inlined ones of the same name.
+func jsonFields(types map[string]*ast.StructType, st *ast.StructType) []jsonField {
+	return collectJSONFields(types, st, map[*ast.StructType]bool{})
+}
+
+func collectJSONFields(types map[string]*ast.StructType, st *ast.StructType, embedding map[*ast.StructType]bool) []jsonField {
+	embedding[st] = true // struct embedding itself via pointer is inlined once
+	defer delete(embedding, st)
+	var fields []jsonField
+	declared := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			typeName := embeddedTypeName(field.Type)
+			jsonName, omitEmpty, skip := jsonFieldName(field, typeName)
+			if skip {
+				continue
+			}
+			if embedded, ok := types[typeName]; ok && jsonName == typeName {
+				if !embedding[embedded] {
+					for _, inlined := range collectJSONFields(types, embedded, embedding) {
+						inlined.inlined = true
+						fields = append(fields, inlined)
+					}
+				}
+				continue
+			}
+			if ast.IsExported(typeName) {
+				fields = append(fields, jsonField{Name: jsonName, Type: field.Type, OmitEmpty: omitEmpty})
+				declared[jsonName] = true
+			}
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			jsonName, omitEmpty, skip := jsonFieldName(field, name.Name)
+			if skip {
+				continue
+			}
+			fields = append(fields, jsonField{Name: jsonName, Type: field.Type, OmitEmpty: omitEmpty})
+			declared[jsonName] = true
+		}
+	}
+	visible := fields[:0]
+	for _, field := range fields {
+		if !field.inlined || !declared[field.Name] {
+			visible = append(visible, field)
+			declared[field.Name] = true // the first of inlined ones of the same name is kept
+		}
+	}
+	return visible
+}
+
+// Name of embedded type : name of field embedding it.
+func embeddedTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedTypeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// Name of field in JSON by its `json` tag.
+func jsonFieldName(field *ast.Field, fieldName string) (name string, omitEmpty, skip bool) {
+	name = fieldName
+	if field.Tag == nil {
+		return
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return
+	}
+	options := strings.Split(reflect.StructTag(tag).Get("json"), ",")
+	if options[0] == "-" && len(options) == 1 {
+		return "", false, true
+	}
+	if options[0] != "" {
+		name = options[0]
+	}
+	for _, option := range options[1:] {
+		omitEmpty = omitEmpty || option == "omitempty"
+	}
+	return
+}
+
+func ref(schemaName string) map[string]interface{} {
+	return map[string]interface{}{"$ref": schemasRef + schemaName}
+}
+
+// Name of type referenced by expression, i.e. `ProfileParams` or `*User` -> `User`.
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
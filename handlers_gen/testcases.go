@@ -0,0 +1,415 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Path no receiver serves, used to check fallback of router.
+const unknownTestPath = "/__unknown_method"
+
+// Data for generating table-driven tests of handlers : cases derived from annotations of every receiver.
+type HandlersTestCodegen struct {
+	Package   string
+	Receivers []*ReceiverTests
+}
+
+type ReceiverTests struct {
+	Receiver    string
+	Constructor string // expression producing receiver, i.e. `NewMyApi()`
+	Cases       []HandlerTestCase
+}
+
+// Request to handler and its expected response. Result is JSON of error in envelope of receiver.
+type HandlerTestCase struct {
+	Name   string
+	Method string
+	Path   string
+	Query  string // url encoded params
+	Auth   bool   // send valid token in `X-Auth` header
+	Status int
+	Result string
+}
+
+// Value of param probed against validators. Format of value is known by construction.
+type probe struct {
+	value       string
+	validFormat bool
+}
+
+// Valid samples of formats supported by validator `format`.
+var formatSamples = map[string]string{
+	"email":    "user@example.com",
+	"uuid":     "123e4567-e89b-12d3-a456-426614174000",
+	"url":      "http://example.com",
+	"ipv4":     "127.0.0.1",
+	"hostname": "example.com",
+	"date":     "2020-01-02",
+}
+
+// English messages of validation errors. Duplicated from runtime catalog on purpose :
+// generated tests check templates against expectations not derived from them.
+var testMessages = map[string]string{
+	"type":      "must be int",
+	"required":  "must me not empty",
+	"enum":      "must be one of [%s]",
+	"bound":     "must be %s",
+	"len_bound": "len must be %s",
+	"format":    "must be valid %s",
+}
+
+// Genereate table-driven tests of handlers into separate file of the same package.
+func handleTestsCodegen(src *SourceFile, path string, templ *template.Template) {
+	out, err := os.Create(path)
+	if err != nil {
+		panic(fmt.Errorf("failed to create tests file [%s]: %s", path, err.Error()))
+	}
+	defer out.Close()
+	if err := templ.Execute(out, buildHandlerTests(src)); err != nil {
+		panic(fmt.Errorf("failed to process template [%s]: %s", templ.Name(), err.Error()))
+	}
+}
+
+// Expected messages are english ones of runtime catalog, so no cases are generated when package registers its own
+// catalogs : they may override them. Receivers choosing error envelope at runtime have no cases either.
+func buildHandlerTests(src *SourceFile) *HandlersTestCodegen {
+	result := &HandlersTestCodegen{Package: src.Package}
+	if registersCatalogs(src) {
+		return result
+	}
+	receivers := make([]string, 0, len(src.Methods))
+	for receiver := range src.Methods {
+		receivers = append(receivers, receiver)
+	}
+	sort.Strings(receivers)
+	for _, receiver := range receivers {
+		envelope := errorEnvelope(src, receiver)
+		if envelope == "" {
+			continue
+		}
+		tests := &ReceiverTests{Receiver: receiver, Constructor: "&" + receiver + "{}"}
+		if _, ok := src.Funcs["New"+receiver]; ok {
+			tests.Constructor = "New" + receiver + "()"
+		}
+		expected := expectedErrors{envelope: envelope}
+		tests.Cases = append(tests.Cases, HandlerTestCase{
+			Name: "unknown path", Method: http.MethodGet, Path: unknownTestPath,
+			Status: http.StatusNotFound, Result: expected.body(http.StatusNotFound, "unknown_method", "", "unknown method"),
+		})
+		for _, api := range src.Methods[receiver] {
+			tests.Cases = append(tests.Cases, endpointCases(src, receiver, api, expected)...)
+		}
+		result.Receivers = append(result.Receivers, tests)
+	}
+	return result
+}
+
+// Message catalogs registered by any function of package, i.e. `RegisterMessageCatalog("en", ...)` within `init`.
+func registersCatalogs(src *SourceFile) bool {
+	registers := false
+	for _, fn := range src.Funcs {
+		ast.Inspect(fn, func(node ast.Node) bool {
+			if call, ok := node.(*ast.CallExpr); ok {
+				if ident, ok := call.Fun.(*ast.Ident); ok {
+					registers = registers || ident.Name == "RegisterMessageCatalog" || ident.Name == "LoadMessageCatalogs"
+				}
+			}
+			return !registers
+		})
+	}
+	return registers
+}
+
+// Cases of endpoint : wrong method, missing authorization and invalid value of every param.
+func endpointCases(src *SourceFile, receiver string, api *ApiGen, expected expectedErrors) (cases []HandlerTestCase) {
+	if len(api.Middleware) > 0 { // middlewares of endpoint may reject any request
+		return
+	}
+	name := api.Target.Name.Name
+	method := api.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if api.Method != "" {
+		wrongMethod := http.MethodGet
+		if api.Method == http.MethodGet {
+			wrongMethod = http.MethodPost
+		}
+		cases = append(cases, HandlerTestCase{
+			Name: name + ": wrong method", Method: wrongMethod, Path: api.Url, Auth: api.Auth.Token,
+			Status: http.StatusNotAcceptable, Result: expected.body(http.StatusNotAcceptable, "bad_method", "", "bad method"),
+		})
+	}
+	if api.Auth.Token {
+		cases = append(cases, HandlerTestCase{
+			Name: name + ": unauthorized", Method: method, Path: api.Url,
+			Status: http.StatusForbidden, Result: expected.body(http.StatusForbidden, "unauthorized", "", "unauthorized"),
+		})
+	}
+	if api.Auth.UseApiKey {
+		// Valid api key can't be derived, so params are not reached. Missing key is rejected only by declared store.
+		if _, ok := src.Funcs[receiver+".ApiKeyStore"]; ok {
+			cases = append(cases, HandlerTestCase{
+				Name: name + ": missing api key", Method: method, Path: api.Url,
+				Status: http.StatusForbidden, Result: expected.body(http.StatusForbidden, "unauthorized", "", "unauthorized"),
+			})
+		}
+		return
+	}
+	params := src.ValidatorOf(typeName(api.ArgType))
+	if params == nil || !isProbeable(src, params) {
+		return
+	}
+	baseline, ok := validBaseline(params)
+	if !ok {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, fieldName := range params.FieldNames() {
+		validator := params.Validators[fieldName]
+		for _, p := range invalidProbes(validator) {
+			code, reason := probeField(validator, p)
+			if reason == "" || seen[validator.ParamName+"="+p.value] {
+				continue
+			}
+			seen[validator.ParamName+"="+p.value] = true
+			query := url.Values{}
+			for param, value := range baseline {
+				query.Set(param, value)
+			}
+			query.Del(validator.ParamName)
+			if p.value != "" {
+				query.Set(validator.ParamName, p.value)
+			}
+			result := expected.body(http.StatusBadRequest, code, validator.ParamName, validator.ParamName+" "+reason)
+			if api.CollectErrors {
+				result = expected.fieldsBody(validator.ParamName, code, reason)
+			}
+			cases = append(cases, HandlerTestCase{
+				Name:   fmt.Sprintf("%s: %s %s %q", name, validator.ParamName, code, p.value),
+				Method: method, Path: api.Url, Query: query.Encode(), Auth: api.Auth.Token,
+				Status: http.StatusBadRequest, Result: result,
+			})
+		}
+	}
+	return
+}
+
+// Params are probed only when outcome of every check is known : user-defined checks, patterns and
+// relations of fields may reject any baseline.
+func isProbeable(src *SourceFile, params *StructValidator) bool {
+	if _, hooked := src.Funcs[params.StructName+".Validate"]; hooked {
+		return false
+	}
+	for _, validator := range params.Validators {
+		if validator.Pattern != "" || len(validator.Custom) > 0 || len(validator.Relations) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Values of params passing every validator, by param name. Invalid cases change one param of baseline.
+func validBaseline(params *StructValidator) (map[string]string, bool) {
+	baseline := make(map[string]string, len(params.Validators))
+	for _, validator := range params.Validators {
+		found := false
+		for _, p := range validProbes(validator) {
+			if _, reason := probeField(validator, p); reason == "" {
+				baseline[validator.ParamName], found = p.value, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return baseline, true
+}
+
+// Candidates of valid value : default, enumerated values, format sample and values on bounds.
+func validProbes(fv *FieldValidator) (probes []probe) {
+	if fv.HasDefault() {
+		probes = append(probes, probe{fmt.Sprint(fv.Default), true})
+	}
+	for _, value := range fv.Enum {
+		probes = append(probes, probe{value, true})
+	}
+	if sample, ok := formatSamples[fv.Format]; ok {
+		probes = append(probes, probe{sample, true})
+	}
+	sizes := []int{1, 0}
+	for _, bound := range fv.Bounds() {
+		switch bound.Operator {
+		case ">":
+			sizes = append(sizes, bound.Value+1)
+		case "<":
+			sizes = append(sizes, bound.Value-1)
+		default:
+			sizes = append(sizes, bound.Value)
+		}
+	}
+	for _, size := range sizes {
+		probes = append(probes, sized(fv, size))
+	}
+	return
+}
+
+// Candidates of invalid value : missing, malformed and just beyond every bound.
+func invalidProbes(fv *FieldValidator) []probe {
+	probes := []probe{{"", true}}
+	if fv.FieldType == IntFieldType {
+		probes = append(probes, probe{"abc", true})
+	}
+	if fv.HasEnumConstraint() {
+		probes = append(probes, probe{"zz_invalid", true})
+	}
+	if fv.Format != "" {
+		probes = append(probes, probe{"!", false})
+	}
+	for _, bound := range fv.Bounds() {
+		switch bound.Kind {
+		case minValueValidator:
+			probes = append(probes, sized(fv, bound.Value-1))
+		case maxValueValidator, lenValueValidator:
+			probes = append(probes, sized(fv, bound.Value+1))
+		default: // exclusive bounds
+			probes = append(probes, sized(fv, bound.Value))
+		}
+	}
+	return probes
+}
+
+// Int value or string of given length. Strings of negative length don't exist, so they are probed as empty.
+func sized(fv *FieldValidator, size int) probe {
+	if fv.FieldType == IntFieldType {
+		return probe{strconv.Itoa(size), true}
+	}
+	if size < 0 {
+		size = 0
+	}
+	return probe{strings.Repeat("a", size), false}
+}
+
+// Code and english reason of the first check value of param fails the same way as generated validator does.
+// Empty reason - value is valid.
+func probeField(fv *FieldValidator, p probe) (code, reason string) {
+	value := p.value
+	var number int
+	if fv.FieldType == IntFieldType {
+		var err error
+		if number, err = strconv.Atoi(value); err != nil {
+			return "type", testMessages["type"]
+		}
+	} else {
+		if fv.Trim {
+			value = strings.TrimSpace(value)
+		}
+		if fv.Collapse {
+			value = strings.Join(strings.Fields(value), " ")
+		}
+		if fv.Lower {
+			value = strings.ToLower(value)
+		}
+		if fv.Upper {
+			value = strings.ToUpper(value)
+		}
+		if value == "" && fv.HasDefault() {
+			value, p.validFormat = fmt.Sprint(fv.Default), true
+		}
+		number = utf8.RuneCountInString(value)
+		if fv.CountBytes {
+			number = len(value)
+		}
+	}
+	if fv.Required && value == "" {
+		return "required", testMessages["required"]
+	}
+	if fv.HasEnumConstraint() && !containsString(fv.Enum, value) {
+		return "enum", fmt.Sprintf(testMessages["enum"], fv.StringifyEnum())
+	}
+	for _, bound := range fv.Bounds() {
+		if !satisfiesBound(number, bound) {
+			if fv.FieldType == IntFieldType {
+				return bound.Kind, fmt.Sprintf(testMessages["bound"], bound.Relation())
+			}
+			return bound.Kind, fmt.Sprintf(testMessages["len_bound"], bound.Relation())
+		}
+	}
+	if fv.Format != "" && value != "" && !p.validFormat {
+		return "format", fmt.Sprintf(testMessages["format"], fv.Format)
+	}
+	return "", ""
+}
+
+func satisfiesBound(value int, bound Bound) bool {
+	switch bound.Operator {
+	case "==":
+		return value == bound.Value
+	case ">=":
+		return value >= bound.Value
+	case ">":
+		return value > bound.Value
+	case "<=":
+		return value <= bound.Value
+	}
+	return value < bound.Value
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Expected error responses in envelope of receiver, one of `LegacyEnvelope`, `CodedEnvelope`, `ProblemEnvelope`.
+type expectedErrors struct {
+	envelope string
+}
+
+// JSON of error with code and param it relates to. Legacy envelope carries only text of error.
+func (e expectedErrors) body(status int, code, field, text string) string {
+	body := map[string]interface{}{}
+	if e.envelope != legacyEnvelope {
+		body["code"] = code
+		if field != "" {
+			body["field"] = field
+		}
+	}
+	return e.encode(status, body, text)
+}
+
+// JSON of failed validation of collected params, reason of failure by param.
+func (e expectedErrors) fieldsBody(param, code, reason string) string {
+	body := map[string]interface{}{"fields": map[string]string{param: reason}}
+	if e.envelope != legacyEnvelope {
+		body["code"] = "validation_failed"
+		body["details"] = map[string]string{param: code}
+	}
+	return e.encode(http.StatusBadRequest, body, "validation failed")
+}
+
+func (e expectedErrors) encode(status int, body map[string]interface{}, text string) string {
+	if e.envelope == problemEnvelope {
+		body["type"] = "about:blank"
+		body["title"] = http.StatusText(status)
+		body["status"] = status
+		body["detail"] = text
+	} else {
+		body["error"] = text
+	}
+	encoded, _ := json.Marshal(body)
+	return string(encoded)
+}
@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+type UploadApi struct{}
+
+type UploadParams struct {
+	Name string `apivalidator:"required"`
+}
+
+// apigen:api {"url": "/upload", "method": "POST", "maxBody": "1TB"}
+func (api *UploadApi) Upload(ctx context.Context, in UploadParams) (*UploadParams, error) {
+	return &in, nil
+}
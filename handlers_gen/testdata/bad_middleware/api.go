@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+type WrappedApi struct{}
+
+type WrappedParams struct {
+	ID int `apivalidator:"min=1"`
+}
+
+func (api *WrappedApi) audit(next http.HandlerFunc) http.HandlerFunc {
+	return next
+}
+
+// apigen:api {"url": "/wrapped", "middleware": ["audit"]}
+func (api *WrappedApi) Wrapped(ctx context.Context, in WrappedParams) (*WrappedParams, error) {
+	return &in, nil
+}
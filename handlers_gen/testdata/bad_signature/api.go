@@ -0,0 +1,12 @@
+package main
+
+type SignatureApi struct{}
+
+type SignatureParams struct {
+	Login string `apivalidator:"required"`
+}
+
+// apigen:api {"url": "/signature"}
+func (api *SignatureApi) Lookup(in SignatureParams) *SignatureParams {
+	return &in
+}
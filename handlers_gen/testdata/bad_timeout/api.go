@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+type SlowApi struct{}
+
+type SlowParams struct {
+	Delay int `apivalidator:"min=0"`
+}
+
+// apigen:api {"url": "/slow", "timeout": "2 seconds"}
+func (api *SlowApi) Slow(ctx context.Context, in SlowParams) (*SlowParams, error) {
+	return &in, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import "context"
+
+type UploadApi struct{}
+
+type UploadParams struct {
+	Name    string `apivalidator:"required"`
+	Content string `apivalidator:"max=4096"`
+}
+
+// apigen:api {"url": "/upload", "method": "POST", "maxBody": "8KB"}
+func (api *UploadApi) Upload(ctx context.Context, in UploadParams) (*UploadParams, error) {
+	return &in, nil
+}
+
+// apigen:api {"url": "/upload/meta", "method": "POST"}
+func (api *UploadApi) Meta(ctx context.Context, in UploadParams) (*UploadParams, error) {
+	return &in, nil
+}
@@ -0,0 +1,15 @@
+package main
+
+import "context"
+
+type SignupApi struct{}
+
+type SignupParams struct {
+	Login string `apivalidator:"required,custom=notReserved|noSpaces"`
+	Age   int    `apivalidator:"custom=adult"`
+}
+
+// apigen:api {"url": "/signup", "method": "POST"}
+func (api *SignupApi) Signup(ctx context.Context, in SignupParams) (*SignupParams, error) {
+	return &in, nil
+}
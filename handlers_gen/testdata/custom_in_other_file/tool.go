@@ -0,0 +1,6 @@
+// Tool next to package sources is not part of package : its function is not a validator.
+package tool
+
+func adult(age string) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+func (p *SignupParams) notReserved(login string) error {
+	if login == "root" {
+		return errors.New("is reserved")
+	}
+	return nil
+}
+
+func noSpaces(value string) error {
+	if strings.Contains(value, " ") {
+		return errors.New("must not contain spaces")
+	}
+	return nil
+}
+
+func adult(age int) error {
+	if age < 18 {
+		return errors.New("must be adult")
+	}
+	return nil
+}
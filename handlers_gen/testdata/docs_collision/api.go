@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+type ItemApi struct{}
+
+type ItemParams struct {
+	ID int `apivalidator:"min=1"`
+}
+
+// apigen:api {"url": "/_docs/openapi.json"}
+func (api *ItemApi) Get(ctx context.Context, in ItemParams) (int, error) {
+	return in.ID, nil
+}
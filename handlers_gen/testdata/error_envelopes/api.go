@@ -0,0 +1,47 @@
+package main
+
+import "context"
+
+type CodedApi struct{}
+
+type ProblemApi struct{}
+
+// Envelope is chosen by configuration at runtime.
+type ConfiguredApi struct {
+	envelope ErrorEnvelope
+}
+
+func (api *CodedApi) ErrorEnvelope() ErrorEnvelope {
+	return CodedEnvelope
+}
+
+func (api *ProblemApi) ErrorEnvelope() ErrorEnvelope {
+	return ProblemEnvelope
+}
+
+func (api *ConfiguredApi) ErrorEnvelope() ErrorEnvelope {
+	return api.envelope
+}
+
+type ItemParams struct {
+	ID int `apivalidator:"min=1"`
+}
+
+type Item struct {
+	ID int `json:"id"`
+}
+
+// apigen:api {"url": "/item"}
+func (api *CodedApi) Get(ctx context.Context, in ItemParams) (*Item, error) {
+	return &Item{ID: in.ID}, nil
+}
+
+// apigen:api {"url": "/item", "method": "POST", "collectErrors": true}
+func (api *ProblemApi) Get(ctx context.Context, in ItemParams) (*Item, error) {
+	return &Item{ID: in.ID}, nil
+}
+
+// apigen:api {"url": "/item", "auth": true}
+func (api *ConfiguredApi) Get(ctx context.Context, in ItemParams) (*Item, error) {
+	return &Item{ID: in.ID}, nil
+}
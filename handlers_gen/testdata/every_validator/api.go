@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+type ValidatorsApi struct{}
+
+type EveryParams struct {
+	Login    string `apivalidator:"required,trim,lower,min=3,max=16,custom=notReserved|noDots"`
+	Name     string `apivalidator:"paramname=full_name,collapse,bytes,lt=64"`
+	Code     string `apivalidator:"upper,len=2"`
+	Role     string `apivalidator:"enum=user|moderator|admin,default=user"`
+	Age      int    `apivalidator:"min=0,max=128,default=18"`
+	Score    int    `apivalidator:"gt=0,lt=100"`
+	Email    string `apivalidator:"format=email"`
+	Phone    string `apivalidator:"oneof_required=Email|Phone"`
+	Token    string `apivalidator:"required_if=Role admin"`
+	Since    string `apivalidator:"format=date"`
+	Until    string `apivalidator:"format=date,gtefield=Since"`
+	MinAge   int    `apivalidator:"ltefield=Age"`
+	Nickname string `apivalidator:"min=1,pattern=^[a-z,]{2,8}$"`
+}
+
+func (p *EveryParams) notReserved(login string) error {
+	if login == "root" {
+		return errors.New("is reserved")
+	}
+	return nil
+}
+
+func noDots(value string) error {
+	for _, r := range value {
+		if r == '.' {
+			return errors.New("must not contain dots")
+		}
+	}
+	return nil
+}
+
+func (p *EveryParams) Validate() error {
+	return nil
+}
+
+// apigen:api {"url": "/every", "method": "POST", "collectErrors": true}
+func (api *ValidatorsApi) Every(ctx context.Context, in EveryParams) (*EveryParams, error) {
+	return &in, nil
+}
@@ -0,0 +1,40 @@
+package apikey
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+// Receiver authorizing requests by api keys of store.
+type KeysApi struct {
+	store ApiKeyStore
+}
+
+func (api *KeysApi) ApiKeyStore() ApiKeyStore {
+	return api.store
+}
+
+type OrderParams struct {
+	ID int `apivalidator:"min=1"`
+}
+
+type Order struct {
+	ID    int    `json:"id"`
+	State string `json:"state"`
+}
+
+// apigen:api {"url": "/orders", "auth": {"apikey": ["orders:read"]}}
+func (api *KeysApi) Get(ctx context.Context, in OrderParams) (*Order, error) {
+	return &Order{ID: in.ID, State: "new"}, nil
+}
+
+// apigen:api {"url": "/orders/pay", "auth": {"apikey": ["orders:read", "orders:write"]}, "method": "POST"}
+func (api *KeysApi) Pay(ctx context.Context, in OrderParams) (*Order, error) {
+	return &Order{ID: in.ID, State: "paid"}, nil
+}
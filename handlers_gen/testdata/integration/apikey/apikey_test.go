@@ -0,0 +1,92 @@
+package apikey
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Request of server with api key in header, in query param or without it. Returns status and error text.
+func requestWithKey(t *testing.T, h http.Handler, method, path, key string, inQuery bool) (int, string) {
+	t.Helper()
+	query := url.Values{"id": {"7"}}
+	if key != "" && inQuery {
+		query.Set("api_key", key)
+	}
+	var r *http.Request
+	if method == http.MethodPost {
+		r = httptest.NewRequest(method, path, strings.NewReader(query.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		r = httptest.NewRequest(method, path+"?"+query.Encode(), nil)
+	}
+	if key != "" && !inQuery {
+		r.Header.Set("X-Api-Key", key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("cant unpack json %q: %v", w.Body, err)
+	}
+	return w.Code, body.Error
+}
+
+func TestApiKeyAuthorization(t *testing.T) {
+	h := &KeysApi{store: MemoryApiKeyStore{
+		HashApiKey("reader-key"): {ID: "reader", Scopes: []string{"orders:read"}},
+		HashApiKey("writer-key"): {ID: "writer", Scopes: []string{"orders:read", "orders:write"}},
+	}}
+	for _, c := range []struct {
+		name, method, path, key string
+		inQuery                 bool
+		status                  int
+		error                   string
+	}{
+		{"missing key", http.MethodGet, "/orders", "", false, http.StatusForbidden, "unauthorized"},
+		{"unknown key", http.MethodGet, "/orders", "forged-key", false, http.StatusForbidden, "unauthorized"},
+		{"key in header", http.MethodGet, "/orders", "reader-key", false, http.StatusOK, ""},
+		{"key in query", http.MethodGet, "/orders", "reader-key", true, http.StatusOK, ""},
+		{"insufficient scope", http.MethodPost, "/orders/pay", "reader-key", false, http.StatusForbidden, "insufficient scope"},
+		{"every scope", http.MethodPost, "/orders/pay", "writer-key", false, http.StatusOK, ""},
+		{"key in query of POST", http.MethodPost, "/orders/pay", "writer-key", true, http.StatusForbidden, "unauthorized"},
+	} {
+		if status, text := requestWithKey(t, h, c.method, c.path, c.key, c.inQuery); status != c.status || text != c.error {
+			t.Errorf("%s: expected %d %q, got %d %q", c.name, c.status, c.error, status, text)
+		}
+	}
+}
+
+func TestApiKeyRateLimit(t *testing.T) {
+	h := &KeysApi{store: MemoryApiKeyStore{
+		HashApiKey("limited-key"): {ID: "limited", Scopes: []string{"orders:read"}, Rate: 0.001, Burst: 2},
+		HashApiKey("key-a"):       {Scopes: []string{"orders:read"}, Rate: 0.001, Burst: 1},
+		HashApiKey("key-b"):       {Scopes: []string{"orders:read"}, Rate: 0.001, Burst: 1},
+	}}
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if status, text := requestWithKey(t, h, http.MethodGet, "/orders", "limited-key", false); status != expected {
+			t.Fatalf("request %d: expected %d, got %d %q", i, expected, status, text)
+		}
+	}
+	if _, text := requestWithKey(t, h, http.MethodGet, "/orders", "limited-key", false); text != "rate limit exceeded" {
+		t.Errorf("expected rate limit error, got %q", text)
+	}
+	// Keys without ID are limited separately.
+	for _, key := range []string{"key-a", "key-b"} {
+		if status, text := requestWithKey(t, h, http.MethodGet, "/orders", key, false); status != http.StatusOK {
+			t.Errorf("%s: expected own bucket, got %d %q", key, status, text)
+		}
+	}
+}
+
+func TestApiKeyStoreMissing(t *testing.T) {
+	status, text := requestWithKey(t, &KeysApi{}, http.MethodGet, "/orders", "reader-key", false)
+	if status != http.StatusInternalServerError || text != "api key store is not configured" {
+		t.Errorf("expected missing store error, got %d %q", status, text)
+	}
+}
@@ -0,0 +1,28 @@
+package catalog
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+// English messages differ from default ones, so expectations of generated tests don't hold.
+func init() {
+	RegisterMessageCatalog("en", MessageCatalog{"required": "is required"})
+}
+
+type GreetApi struct{}
+
+type GreetParams struct {
+	Name string `apivalidator:"required"`
+}
+
+// apigen:api {"url": "/greet"}
+func (api *GreetApi) Greet(ctx context.Context, in GreetParams) (string, error) {
+	return "hello, " + in.Name, nil
+}
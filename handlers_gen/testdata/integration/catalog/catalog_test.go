@@ -0,0 +1,16 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisteredCatalog(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&GreetApi{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/greet", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "name is required") {
+		t.Fatalf("expected message of registered catalog, got %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type OrderParams struct {
+	Item     string `apivalidator:"required,enum=book|pen"`
+	Quantity int    `apivalidator:"min=1,max=10"`
+}
+
+type Order struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// Responds with errors in RFC 7807 problem envelope.
+type ProblemApi struct{}
+
+func (api *ProblemApi) ErrorEnvelope() ErrorEnvelope { return ProblemEnvelope }
+
+// apigen:api {"url": "/order"}
+func (api *ProblemApi) Order(ctx context.Context, in OrderParams) (*Order, error) {
+	return &Order{Item: in.Item, Quantity: in.Quantity}, nil
+}
+
+// Responds with errors in legacy envelope.
+type LegacyApi struct{}
+
+// apigen:api {"url": "/order", "collectErrors": true}
+func (api *LegacyApi) Order(ctx context.Context, in OrderParams) (*Order, error) {
+	return &Order{Item: in.Item, Quantity: in.Quantity}, nil
+}
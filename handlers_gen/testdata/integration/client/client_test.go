@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClientOfProblemEnvelope(t *testing.T) {
+	server := httptest.NewServer(&ProblemApi{})
+	defer server.Close()
+	client := NewProblemApiClient(server.URL)
+
+	order, err := client.Order(context.Background(), OrderParams{Item: "book", Quantity: 2})
+	if err != nil || *order != (Order{Item: "book", Quantity: 2}) {
+		t.Fatalf("unexpected result %+v, %v", order, err)
+	}
+
+	_, err = client.Order(context.Background(), OrderParams{Item: "book"})
+	var apiError ApiError
+	if !errors.As(err, &apiError) || apiError.HTTPStatus != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+	if err.Error() != "quantity must be >= 1" {
+		t.Errorf("expected message of problem detail, got %q", err.Error())
+	}
+	var detailedErr *DetailedError
+	if !errors.As(apiError.Err, &detailedErr) || detailedErr.Code != "min" || detailedErr.Field != "quantity" {
+		t.Errorf("expected code and field of error, got %#v", detailedErr)
+	}
+}
+
+func TestClientOfCollectedErrors(t *testing.T) {
+	server := httptest.NewServer(&LegacyApi{})
+	defer server.Close()
+
+	_, err := NewLegacyApiClient(server.URL).Order(context.Background(), OrderParams{Item: "car", Quantity: 20})
+	var apiError ApiError
+	var validationErr *ValidationError
+	if !errors.As(err, &apiError) || !errors.As(apiError.Err, &validationErr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	expected := map[string]string{"item": "must be one of [book, pen]", "quantity": "must be <= 10"}
+	if !reflect.DeepEqual(validationErr.Fields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, validationErr.Fields)
+	}
+}
@@ -0,0 +1,27 @@
+package docs
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type CatalogApi struct{}
+
+type SearchParams struct {
+	Query string `apivalidator:"required,max=64"`
+}
+
+type Result struct {
+	Items []string `json:"items"`
+}
+
+// apigen:api {"url": "/search"}
+func (api *CatalogApi) Search(ctx context.Context, in SearchParams) (*Result, error) {
+	return &Result{Items: []string{in.Query}}, nil
+}
@@ -0,0 +1,57 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDocsRoutes(t *testing.T) {
+	h := &CatalogApi{}
+	for _, c := range []struct {
+		method, path string
+		status       int
+		contentType  string
+	}{
+		{http.MethodGet, "/_docs/", http.StatusOK, "text/html; charset=utf-8"},
+		{http.MethodHead, "/_docs/", http.StatusOK, "text/html; charset=utf-8"},
+		{http.MethodGet, "/_docs/openapi.json", http.StatusOK, "application/json"},
+		{http.MethodHead, "/_docs/openapi.json", http.StatusOK, "application/json"},
+		{http.MethodPost, "/_docs/", http.StatusMethodNotAllowed, "text/plain; charset=utf-8"},
+		{http.MethodPut, "/_docs/openapi.json", http.StatusMethodNotAllowed, "text/plain; charset=utf-8"},
+		{http.MethodDelete, "/_docs/openapi.json", http.StatusMethodNotAllowed, "text/plain; charset=utf-8"},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code != c.status || w.Header().Get("Content-Type") != c.contentType {
+			t.Errorf("%s %s: expected %d %q, got %d %q", c.method, c.path, c.status, c.contentType, w.Code, w.Header().Get("Content-Type"))
+		}
+		if c.status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != "GET, HEAD" {
+			t.Errorf("%s %s: expected allowed methods, got %q", c.method, c.path, w.Header().Get("Allow"))
+		}
+	}
+}
+
+func TestDocsContent(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&CatalogApi{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_docs/openapi.json", nil))
+	var doc struct {
+		Openapi string                     `json:"openapi"`
+		Info    struct{ Title string }     `json:"info"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	if doc.Openapi != "3.1.0" || doc.Info.Title != "CatalogApi" || doc.Paths["/search"] == nil {
+		t.Errorf("unexpected OpenAPI document: %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	(&CatalogApi{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_docs/", nil))
+	if !strings.Contains(w.Body.String(), "openapi.json") {
+		t.Errorf("docs page does not load document: %s", w.Body)
+	}
+}
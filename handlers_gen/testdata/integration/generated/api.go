@@ -0,0 +1,78 @@
+package generated
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type UserApi struct {
+	users map[string]*User
+}
+
+func NewUserApi() *UserApi {
+	return &UserApi{users: map[string]*User{"rvasily": {Login: "rvasily", Age: 42}}}
+}
+
+type LookupParams struct {
+	Login string `apivalidator:"required,trim,lower,min=3,max=16"`
+}
+
+type UpdateParams struct {
+	Login string `apivalidator:"required,min=3"`
+	Email string `apivalidator:"format=email"`
+	Role  string `apivalidator:"enum=user|moderator|admin,default=user"`
+	Age   int    `apivalidator:"min=0,max=128"`
+}
+
+type User struct {
+	Login string `json:"login"`
+	Email string `json:"email,omitempty"`
+	Role  string `json:"role"`
+	Age   int    `json:"age"`
+}
+
+// apigen:api {"url": "/user/lookup"}
+func (api *UserApi) Lookup(ctx context.Context, in LookupParams) (*User, error) {
+	return api.users[in.Login], nil
+}
+
+// apigen:api {"url": "/user/update", "auth": true, "method": "POST", "collectErrors": true}
+func (api *UserApi) Update(ctx context.Context, in UpdateParams) (*User, error) {
+	return &User{Login: in.Login, Email: in.Email, Role: in.Role, Age: in.Age}, nil
+}
+
+// Responds with errors in coded envelope.
+type CodedApi struct{}
+
+func (api *CodedApi) ErrorEnvelope() ErrorEnvelope { return CodedEnvelope }
+
+// apigen:api {"url": "/user/lookup", "auth": true}
+func (api *CodedApi) Lookup(ctx context.Context, in LookupParams) (*User, error) {
+	return &User{Login: in.Login}, nil
+}
+
+// apigen:api {"url": "/user/update", "method": "POST", "collectErrors": true}
+func (api *CodedApi) Update(ctx context.Context, in UpdateParams) (*User, error) {
+	return &User{Login: in.Login, Email: in.Email, Role: in.Role, Age: in.Age}, nil
+}
+
+// Responds with errors in RFC 7807 problem envelope.
+type ProblemApi struct{}
+
+func (api *ProblemApi) ErrorEnvelope() ErrorEnvelope { return ProblemEnvelope }
+
+// apigen:api {"url": "/user/lookup", "method": "GET"}
+func (api *ProblemApi) Lookup(ctx context.Context, in LookupParams) (*User, error) {
+	return &User{Login: in.Login}, nil
+}
+
+// apigen:api {"url": "/user/update", "method": "POST", "collectErrors": true}
+func (api *ProblemApi) Update(ctx context.Context, in UpdateParams) (*User, error) {
+	return &User{Login: in.Login, Email: in.Email, Role: in.Role, Age: in.Age}, nil
+}
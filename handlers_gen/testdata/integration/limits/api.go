@@ -0,0 +1,23 @@
+package limits
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type UploadApi struct{}
+
+type NoteParams struct {
+	Text string `apivalidator:"paramname=text"`
+}
+
+// apigen:api {"url": "/note", "method": "POST", "maxBody": "1KB"}
+func (api *UploadApi) Note(ctx context.Context, in NoteParams) (int, error) {
+	return len(in.Text), nil
+}
@@ -0,0 +1,76 @@
+package limits
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postNote(body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/note", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	(&UploadApi{}).ServeHTTP(w, r)
+	return w
+}
+
+func TestBodyLimits(t *testing.T) {
+	repeated := func(format string, count int) string {
+		pairs := make([]string, count)
+		for i := range pairs {
+			pairs[i] = fmt.Sprintf(format, i)
+		}
+		return strings.Join(pairs, "&")
+	}
+	defer func() { MaxFormKeys, MaxFormValues = 100, 1000 }()
+	MaxFormKeys, MaxFormValues = 10, 20
+	for name, tc := range map[string]struct {
+		body   string
+		status int
+		error  string
+	}{
+		"fits":            {body: "text=hello", status: http.StatusOK},
+		"oversized body":  {body: "text=" + strings.Repeat("a", 1024), status: http.StatusRequestEntityTooLarge, error: "request body too large"},
+		"too many keys":   {body: repeated("k%d=v", 11), status: http.StatusRequestEntityTooLarge, error: "too many params"},
+		"too many values": {body: repeated("text=%d", 21), status: http.StatusRequestEntityTooLarge, error: "too many params"},
+	} {
+		w := postNote(tc.body)
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != tc.status || body.Error != tc.error {
+			t.Errorf("%s: expected %d %q, got %d %s", name, tc.status, tc.error, w.Code, w.Body)
+		}
+	}
+}
+
+func TestSlowBody(t *testing.T) {
+	BodyReadTimeout = 50 * time.Millisecond
+	defer func() { BodyReadTimeout = 10 * time.Second }()
+	ts := httptest.NewServer(&UploadApi{})
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer conn.Close()
+	// Body is announced but never sent.
+	fmt.Fprintf(conn, "POST /note HTTP/1.1\r\nHost: test\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 100\r\n\r\ntext=")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("expected response to slow client, got %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("expected request timeout, got %d", resp.StatusCode)
+	}
+}
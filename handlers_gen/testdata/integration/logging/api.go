@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+// Logs requests with logger given by test.
+type LoggedApi struct {
+	logger *slog.Logger
+}
+
+func (api *LoggedApi) Logger() *slog.Logger { return api.logger }
+
+type OrderParams struct {
+	Item     string `apivalidator:"required"`
+	Quantity int    `apivalidator:"min=1"`
+}
+
+// apigen:api {"url": "/order", "collectErrors": true}
+func (api *LoggedApi) Order(ctx context.Context, in OrderParams) (int, error) {
+	switch in.Item {
+	case "broken":
+		return 0, errors.New("storage is broken")
+	case "panic":
+		panic("boom")
+	}
+	return in.Quantity, nil
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Serve request and collect records of request log, i.e. {"msg": "request", "status": 200, ...}.
+func serveLogged(t *testing.T, query string) (records []map[string]interface{}) {
+	t.Helper()
+	var out bytes.Buffer
+	api := &LoggedApi{logger: slog.New(slog.NewJSONHandler(&out, nil))}
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/order?"+query, nil))
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		if record["msg"] == "request" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func TestRequestLog(t *testing.T) {
+	for _, tc := range []struct {
+		query      string
+		status     float64
+		validation map[string]interface{}
+		cause      interface{}
+	}{
+		{query: "item=book&quantity=2", status: http.StatusOK},
+		{query: "quantity=0", status: http.StatusBadRequest, validation: map[string]interface{}{
+			"item": "must me not empty", "quantity": "must be >= 1",
+		}},
+		{query: "item=broken&quantity=1", status: http.StatusInternalServerError, cause: "storage is broken"},
+		{query: "item=panic&quantity=1", status: http.StatusInternalServerError, cause: "panic: boom"},
+	} {
+		records := serveLogged(t, tc.query)
+		if len(records) != 1 {
+			t.Fatalf("%s: expected one record of request, got %v", tc.query, records)
+		}
+		record := records[0]
+		if record["endpoint"] != "LoggedApi.Order" || record["status"] != tc.status {
+			t.Errorf("%s: expected endpoint and status %v, got %v", tc.query, tc.status, record)
+		}
+		if latency, ok := record["latency"].(float64); !ok || latency <= 0 {
+			t.Errorf("%s: expected latency, got %v", tc.query, record["latency"])
+		}
+		if validation, _ := record["validation"].(map[string]interface{}); !reflect.DeepEqual(validation, tc.validation) {
+			t.Errorf("%s: expected validation %v, got %v", tc.query, tc.validation, record["validation"])
+		}
+		if record["error"] != tc.cause {
+			t.Errorf("%s: expected cause %v, got %v", tc.query, tc.cause, record["error"])
+		}
+	}
+}
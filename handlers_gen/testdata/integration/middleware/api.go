@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type AuditApi struct{}
+
+// Every request of receiver is numbered by counter living in wrapper, as state of limiter would.
+func (api *AuditApi) Middlewares() []Middleware {
+	return []Middleware{func(next http.Handler) http.Handler {
+		served := 0
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served++
+			w.Header().Set("X-Served", strconv.Itoa(served))
+			next.ServeHTTP(w, r)
+		})
+	}}
+}
+
+type EchoParams struct {
+	Text string `apivalidator:"required"`
+}
+
+// apigen:api {"url": "/echo", "middleware": ["audit"]}
+func (api *AuditApi) Echo(ctx context.Context, in EchoParams) (string, error) {
+	return in.Text, nil
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestMiddlewaresKeepState(t *testing.T) {
+	handler := (&AuditApi{}).Handler()
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echo?text=hi", nil))
+		return w
+	}
+
+	if w := serve(); w.Code != http.StatusInternalServerError || w.Header().Get("X-Served") != "1" {
+		t.Fatalf("expected internal error of missing middleware within receiver middlewares, got %d %s", w.Code, w.Body)
+	}
+
+	audited := 0
+	RegisterMiddleware("audit", func(next http.Handler) http.Handler {
+		wrapped := 0
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped++
+			audited = wrapped
+			next.ServeHTTP(w, r)
+		})
+	})
+	for i := 2; i <= 3; i++ {
+		w := serve()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected middleware registered after the first request to be used, got %d %s", w.Code, w.Body)
+		}
+		if served := w.Header().Get("X-Served"); served != strconv.Itoa(i) {
+			t.Errorf("expected state of receiver middleware to be kept, got request number %s instead of %d", served, i)
+		}
+	}
+	if audited != 2 {
+		t.Errorf("expected state of registered middleware to be kept, got %d requests", audited)
+	}
+}
@@ -0,0 +1,24 @@
+package normalization
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type ProfileApi struct{}
+
+type ProfileParams struct {
+	Nickname string `apivalidator:"trim,collapse,default=anonymous"`
+	Role     string `apivalidator:"lower,enum=user|admin,default=user"`
+}
+
+// apigen:api {"url": "/profile"}
+func (api *ProfileApi) Profile(ctx context.Context, in ProfileParams) (*ProfileParams, error) {
+	return &in, nil
+}
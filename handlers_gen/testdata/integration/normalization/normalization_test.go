@@ -0,0 +1,29 @@
+package normalization
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultOfNormalizedValue(t *testing.T) {
+	for query, expected := range map[string]ProfileParams{
+		"":                                {Nickname: "anonymous", Role: "user"},
+		"nickname=%20%20&role=":           {Nickname: "anonymous", Role: "user"},
+		"nickname=%20Ivan%20%20Ivanov%20": {Nickname: "Ivan Ivanov", Role: "user"},
+		"role=ADMIN":                      {Nickname: "anonymous", Role: "admin"},
+	} {
+		w := httptest.NewRecorder()
+		(&ProfileApi{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profile?"+query, nil))
+		var body struct {
+			Response ProfileParams `json:"response"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("%q: unexpected response %d %s", query, w.Code, w.Body)
+		}
+		if body.Response != expected {
+			t.Errorf("%q: expected %+v, got %+v", query, expected, body.Response)
+		}
+	}
+}
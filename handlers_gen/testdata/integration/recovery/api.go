@@ -0,0 +1,40 @@
+package recovery
+
+import (
+	"context"
+	"net/http"
+)
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+// Panics in every method, recovered panics are kept instead of being logged.
+type PanicApi struct {
+	recovered []interface{}
+}
+
+func (api *PanicApi) ErrorEnvelope() ErrorEnvelope { return CodedEnvelope }
+
+func (api *PanicApi) PanicLogger() PanicLogger { return api }
+
+func (api *PanicApi) LogPanic(r *http.Request, recovered interface{}, stack []byte) {
+	api.recovered = append(api.recovered, recovered)
+}
+
+type PanicParams struct {
+	Kind string `apivalidator:"enum=boom|abort,default=boom"`
+}
+
+// apigen:api {"url": "/panic"}
+func (api *PanicApi) Panic(ctx context.Context, in PanicParams) (string, error) {
+	if in.Kind == "abort" {
+		panic(http.ErrAbortHandler)
+	}
+	panic("boom")
+}
@@ -0,0 +1,60 @@
+package recovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func serveRecovering(api *PanicApi, query string) (w *httptest.ResponseRecorder, recovered interface{}) {
+	w = httptest.NewRecorder()
+	defer func() {
+		recovered = recover()
+	}()
+	api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic?"+query, nil))
+	return
+}
+
+func TestPanicIsInternalError(t *testing.T) {
+	api := &PanicApi{}
+	w, recovered := serveRecovering(api, "kind=boom")
+	if recovered != nil {
+		t.Fatalf("expected panic to be recovered, got %v", recovered)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected internal error, got %d %s", w.Code, w.Body)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != "internal" || body["error"] != "internal server error" {
+		t.Errorf("expected internal error in coded envelope, got %s", w.Body)
+	}
+	if !reflect.DeepEqual(api.recovered, []interface{}{"boom"}) {
+		t.Errorf("expected panic to be logged by panic logger of receiver, got %v", api.recovered)
+	}
+}
+
+func TestPanicDebug(t *testing.T) {
+	PanicDebug = true
+	defer func() { PanicDebug = false }()
+	w, recovered := serveRecovering(&PanicApi{}, "kind=boom")
+	if recovered != "boom" {
+		t.Fatalf("expected panic to propagate, got %v", recovered)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected internal error to be written before panic propagates, got %d", w.Code)
+	}
+}
+
+func TestAbortHandlerIsNotRecovered(t *testing.T) {
+	api := &PanicApi{}
+	w, recovered := serveRecovering(api, "kind=abort")
+	if recovered != http.ErrAbortHandler {
+		t.Fatalf("expected aborted handler to propagate, got %v", recovered)
+	}
+	if w.Body.Len() != 0 || len(api.recovered) != 0 {
+		t.Errorf("expected aborted handler to be neither answered nor logged, got %q, %v", w.Body, api.recovered)
+	}
+}
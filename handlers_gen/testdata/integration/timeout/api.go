@@ -0,0 +1,58 @@
+package timeout
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+type SlowApi struct {
+	logger *slog.Logger
+	panics chan []byte // stacks of recovered panics, logged by Logger when nil
+}
+
+func (api *SlowApi) Logger() *slog.Logger { return api.logger }
+
+func (api *SlowApi) PanicLogger() PanicLogger {
+	if api.panics == nil {
+		return nil
+	}
+	return api
+}
+
+func (api *SlowApi) LogPanic(r *http.Request, recovered interface{}, stack []byte) {
+	api.panics <- stack
+}
+
+type WaitParams struct {
+	Mode string `apivalidator:"enum=fast|ignore|late|cancel|panic|late_panic,default=fast"`
+}
+
+// apigen:api {"url": "/wait", "timeout": "50ms"}
+func (api *SlowApi) Wait(ctx context.Context, in WaitParams) (string, error) {
+	switch in.Mode {
+	case "ignore": // ignores deadline
+		time.Sleep(time.Second)
+	case "late": // succeeds once deadline has passed
+		<-ctx.Done()
+	case "cancel":
+		<-ctx.Done()
+		return "", ctx.Err()
+	case "panic":
+		panic("boom")
+	case "late_panic": // panics once deadline has passed
+		<-ctx.Done()
+		panic("late boom")
+	}
+	return in.Mode, nil
+}
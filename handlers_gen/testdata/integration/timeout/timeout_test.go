@@ -0,0 +1,77 @@
+package timeout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveWait(ctx context.Context, mode string) (*httptest.ResponseRecorder, map[string]interface{}, time.Duration) {
+	var out bytes.Buffer
+	api := &SlowApi{logger: slog.New(slog.NewJSONHandler(&out, nil))}
+	w := httptest.NewRecorder()
+	start := time.Now()
+	api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wait?mode="+mode, nil).WithContext(ctx))
+	elapsed := time.Since(start)
+	var record map[string]interface{}
+	json.Unmarshal(out.Bytes(), &record)
+	return w, record, elapsed
+}
+
+func TestTimeoutBoundsMethod(t *testing.T) {
+	for mode, status := range map[string]int{
+		"fast":   http.StatusOK,
+		"ignore": http.StatusGatewayTimeout,
+		"late":   http.StatusGatewayTimeout,
+		"cancel": http.StatusGatewayTimeout,
+		"panic":  http.StatusInternalServerError,
+	} {
+		w, _, elapsed := serveWait(context.Background(), mode)
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, got %d %s", mode, status, w.Code, w.Body)
+		}
+		if elapsed > 500*time.Millisecond {
+			t.Errorf("%s: expected response within timeout of method, got it in %v", mode, elapsed)
+		}
+	}
+}
+
+func TestClientGoneDuringMethod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	w, record, _ := serveWait(ctx, "ignore")
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("expected nothing written to gone client, got %s", w.Body)
+	}
+	if record["status"] != float64(499) {
+		t.Errorf("expected status of gone client to be logged, got %v", record)
+	}
+}
+
+func TestPanicOfMethodLogged(t *testing.T) {
+	for mode, status := range map[string]int{
+		"panic":      http.StatusInternalServerError,
+		"late_panic": http.StatusGatewayTimeout,
+	} {
+		api := &SlowApi{panics: make(chan []byte, 1)}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wait?mode="+mode, nil))
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, got %d %s", mode, status, w.Code, w.Body)
+		}
+		select {
+		case stack := <-api.panics:
+			if !strings.Contains(string(stack), "(*SlowApi).Wait") {
+				t.Errorf("%s: expected stack of panic site, got %s", mode, stack)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: expected panic to be logged", mode)
+		}
+	}
+}
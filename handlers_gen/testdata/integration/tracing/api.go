@@ -0,0 +1,32 @@
+package tracing
+
+import "context"
+
+type ApiError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (ae ApiError) Error() string {
+	return ae.Err.Error()
+}
+
+// Records spans of requests in memory.
+type TracedApi struct {
+	tracer *MemoryTracer
+}
+
+func NewTracedApi() *TracedApi {
+	return &TracedApi{tracer: &MemoryTracer{}}
+}
+
+func (api *TracedApi) Tracer() Tracer { return api.tracer }
+
+type EchoParams struct {
+	Text string `apivalidator:"required"`
+}
+
+// apigen:api {"url": "/echo"}
+func (api *TracedApi) Echo(ctx context.Context, in EchoParams) (string, error) {
+	return in.Text, nil
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const remoteParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestSpansOfRequest(t *testing.T) {
+	for _, tc := range []struct {
+		query  string
+		status int
+		spans  []string // names of child spans of request in order of their end
+	}{
+		{query: "text=hi", status: http.StatusOK, spans: []string{"extractParams", "validate", "TracedApi.Echo"}},
+		{query: "", status: http.StatusBadRequest, spans: []string{"extractParams", "validate"}},
+	} {
+		api := NewTracedApi()
+		req := httptest.NewRequest(http.MethodGet, "/echo?"+tc.query, nil)
+		req.Header.Set("traceparent", remoteParent)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Fatalf("%q: expected status %d, got %d %s", tc.query, tc.status, w.Code, w.Body)
+		}
+
+		remote, _ := ParseTraceparent(remoteParent)
+		spans := api.tracer.Spans()
+		if len(spans) != len(tc.spans)+1 {
+			t.Fatalf("%q: expected %d spans, got %+v", tc.query, len(tc.spans)+1, spans)
+		}
+		request := spans[len(spans)-1]
+		if request.Name != "GET /echo" || request.Parent != remote {
+			t.Errorf("%q: expected request span child of remote parent, got %+v", tc.query, request)
+		}
+		for key, value := range map[string]interface{}{"http.route": "/echo", "receiver": "TracedApi", "http.status_code": tc.status} {
+			if request.Attributes[key] != value {
+				t.Errorf("%q: expected attribute %s=%v of request span, got %v", tc.query, key, value, request.Attributes[key])
+			}
+		}
+		for i, name := range tc.spans {
+			span := spans[i]
+			if span.Name != name || span.Parent != request.SpanContext {
+				t.Errorf("%q: expected span %s child of request span, got %+v", tc.query, name, span)
+			}
+		}
+		for _, span := range spans {
+			if span.SpanContext.TraceID != remote.TraceID || span.SpanContext.SpanID == remote.SpanID {
+				t.Errorf("%q: expected span %s of remote trace, got %+v", tc.query, span.Name, span.SpanContext)
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+type BrokenApi struct{}
+
+type BrokenParams struct {
+	Login string `apivalidator:"required"`
+}
+
+// apigen:api {"url": "/broken", "method": "POST"
+func (api *BrokenApi) Broken(ctx context.Context, in BrokenParams) (*BrokenParams, error) {
+	return &in, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+type AuditApi struct {
+	log []string
+}
+
+type AuditParams struct {
+	ID int `apivalidator:"min=1"`
+}
+
+// Wraps every request of receiver.
+func (api *AuditApi) Middlewares() []Middleware {
+	return []Middleware{api.recordPath}
+}
+
+func (api *AuditApi) recordPath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.log = append(api.log, r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (api *AuditApi) audit(next http.Handler) http.Handler {
+	return next
+}
+
+// apigen:api {"url": "/audit/read"}
+func (api *AuditApi) Read(ctx context.Context, in AuditParams) (*AuditParams, error) {
+	return &in, nil
+}
+
+// apigen:api {"url": "/audit/write", "method": "POST", "middleware": ["audit", "ratelimit"]}
+func (api *AuditApi) Write(ctx context.Context, in AuditParams) (*AuditParams, error) {
+	return &in, nil
+}
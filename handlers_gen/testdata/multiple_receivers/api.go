@@ -0,0 +1,45 @@
+package main
+
+import "context"
+
+type UserApi struct{}
+
+type OrderApi struct{}
+
+type LookupParams struct {
+	Login string `apivalidator:"required"`
+}
+
+type OrderParams struct {
+	ID    int    `apivalidator:"paramname=order_id,gt=0"`
+	State string `apivalidator:"enum=new|paid|shipped,default=new"`
+}
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type Order struct {
+	ID    int    `json:"id"`
+	State string `json:"state"`
+}
+
+// apigen:api {"url": "/user/lookup"}
+func (api *UserApi) Lookup(ctx context.Context, in LookupParams) (*User, error) {
+	return &User{Login: in.Login}, nil
+}
+
+// apigen:api {"url": "/user/update", "auth": true, "method": "POST"}
+func (api *UserApi) Update(ctx context.Context, in LookupParams) (*User, error) {
+	return &User{Login: in.Login}, nil
+}
+
+// apigen:api {"url": "/order", "method": "GET", "collectErrors": true}
+func (api *OrderApi) Get(ctx context.Context, in OrderParams) (*Order, error) {
+	return &Order{ID: in.ID, State: in.State}, nil
+}
+
+// apigen:api {"url": "/order/pay", "auth": {"apikey": ["orders:write", "payments"]}, "method": "POST"}
+func (api *OrderApi) Pay(ctx context.Context, in OrderParams) (*Order, error) {
+	return &Order{ID: in.ID, State: "paid"}, nil
+}
@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+type NamesApi struct{}
+
+type NameParams struct {
+	Name string `apivalidator:"required,trim,nfc,max=32"`
+}
+
+// apigen:api {"url": "/names"}
+func (api *NamesApi) Normalize(ctx context.Context, in NameParams) (*NameParams, error) {
+	return &in, nil
+}
@@ -0,0 +1,5 @@
+module nfc
+
+go 1.22
+
+require golang.org/x/text v0.42.0
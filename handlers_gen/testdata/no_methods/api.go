@@ -0,0 +1,10 @@
+package main
+
+// Nothing is annotated : only common code is generated.
+type Plain struct {
+	Name string `json:"name"`
+}
+
+func (p *Plain) Method(in string) string {
+	return in
+}
@@ -0,0 +1,14 @@
+package main
+
+import "context"
+
+type CodesApi struct{}
+
+type CodeParams struct {
+	Code string `apivalidator:"pattern=^[a-z]+$,required,max=3"`
+}
+
+// apigen:api {"url": "/codes"}
+func (api *CodesApi) Check(ctx context.Context, in CodeParams) (*CodeParams, error) {
+	return &in, nil
+}
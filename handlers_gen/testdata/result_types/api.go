@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+type ReportsApi struct{}
+
+type ReportParams struct {
+	Kind  string `apivalidator:"required,enum=daily|weekly"`
+	Limit int    `apivalidator:"min=1,max=100,default=10"`
+	Owner string `apivalidator:"paramname=owner-id"`
+	// Schema keeps the tightest of overlapping bounds.
+	Tag    string `apivalidator:"min=10,gt=2,lt=32"`
+	Offset int    `apivalidator:"gt=-1,min=5,max=1000,lt=2000"`
+	Cursor string `apivalidator:"gt=-5,max=40"`
+}
+
+// Tree of nested sections : recursive type is referenced by name.
+type Section struct {
+	Title    string     `json:"title"`
+	Children []*Section `json:"children,omitempty"`
+}
+
+// Fields of embedded struct are inlined in JSON.
+type Audit struct {
+	Author  string    `json:"author"`
+	Updated time.Time `json:"updated,omitempty"`
+	Owner   string    `json:"owner-id"` // hidden by field of Report
+}
+
+type Stamp struct {
+	Seal string `json:"seal"`
+}
+
+type Report struct {
+	Audit
+	*Stamp   `json:"stamp"`     // named by tag, not inlined
+	ID       int64              `json:"id"`
+	Ratio    float64            `json:"ratio"`
+	Public   bool               `json:"public"`
+	Created  time.Time          `json:"created"`
+	Archived *time.Time         `json:"archived"`
+	Raw      []byte             `json:"raw,omitempty"`
+	Tags     []string           `json:"tags"`
+	Counters map[string]uint32  `json:"counters"`
+	Sections map[string]Section `json:"sections"`
+	Root     *Section           `json:"root"`
+	Owner    string             `json:"owner-id"`
+	Untagged string
+	Secret   string `json:"-"`
+	internal string
+	Anything interface{} `json:"anything"`
+}
+
+// apigen:api {"url": "/reports", "auth": {"apikey": ["reports:read"]}}
+func (api *ReportsApi) Get(ctx context.Context, in ReportParams) (*Report, error) {
+	return &Report{}, nil
+}
+
+// apigen:api {"url": "/reports/list", "method": "POST"}
+func (api *ReportsApi) List(ctx context.Context, in ReportParams) ([]Report, error) {
+	return nil, nil
+}
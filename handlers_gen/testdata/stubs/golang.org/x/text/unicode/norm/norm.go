@@ -0,0 +1,8 @@
+// Package norm is stub of golang.org/x/text/unicode/norm with API generated code uses.
+package norm
+
+type Form int
+
+const NFC Form = iota
+
+func (f Form) String(s string) string { return s }
@@ -0,0 +1,4 @@
+package main
+
+type Unfinished struct {
+	Login string `apivalidator:"required"`
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+type SlowApi struct{}
+
+type SlowParams struct {
+	Delay int `apivalidator:"min=0"`
+}
+
+// apigen:api {"url": "/slow", "timeout": "1500ms"}
+func (api *SlowApi) Slow(ctx context.Context, in SlowParams) (*SlowParams, error) {
+	select {
+	case <-time.After(time.Duration(in.Delay) * time.Millisecond):
+		return &in, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// apigen:api {"url": "/fast"}
+func (api *SlowApi) Fast(ctx context.Context, in SlowParams) (*SlowParams, error) {
+	return &in, nil
+}
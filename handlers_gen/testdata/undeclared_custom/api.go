@@ -0,0 +1,9 @@
+package main
+
+type CustomParams struct {
+	Login string `apivalidator:"custom=validLogin"`
+}
+
+func validLogin(login int) error {
+	return nil
+}
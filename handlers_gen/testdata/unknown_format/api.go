@@ -0,0 +1,5 @@
+package main
+
+type FormatParams struct {
+	Phone string `apivalidator:"format=phone"`
+}
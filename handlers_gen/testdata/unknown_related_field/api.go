@@ -0,0 +1,6 @@
+package main
+
+type RangeParams struct {
+	Since string `apivalidator:"format=date"`
+	Until string `apivalidator:"gtfield=Start"`
+}
@@ -0,0 +1,6 @@
+package main
+
+type UnknownParams struct {
+	Login string `apivalidator:"required"`
+	Age   int    `apivalidator:"min=0,between=1|5"`
+}
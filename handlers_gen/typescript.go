@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Data for generating TypeScript client : interfaces of params and results, clients of receivers.
+type TypeScriptCodegen struct {
+	Interfaces []*TsInterface
+	Clients    []*TsClient
+}
+
+type TsInterface struct {
+	Name   string
+	Fields []TsField
+}
+
+type TsField struct {
+	Name     string // quoted if it is not valid identifier
+	Type     string
+	Optional bool
+}
+
+type TsClient struct {
+	Receiver string
+	Methods  []TsMethod
+}
+
+type TsMethod struct {
+	Name       string // name of client method in lower camel case
+	Url        string
+	HttpMethod string
+	Params     string // name of params interface
+	Result     string // TypeScript type of result
+	AuthToken  bool
+	ApiKey     bool
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// TypeScript types of Go builtin types.
+var tsBuiltinTypes = map[string]string{
+	"string": "string", "bool": "boolean", "float32": "number", "float64": "number",
+	"int": "number", "int8": "number", "int16": "number", "int32": "number", "int64": "number",
+	"uint": "number", "uint8": "number", "uint16": "number", "uint32": "number", "uint64": "number",
+}
+
+// Collects interfaces of structs referenced by api on demand.
+type tsBuilder struct {
+	src        *SourceFile
+	interfaces map[string]*TsInterface
+}
+
+// Genereate TypeScript types and fetch-based clients of receivers.
+func handleTypeScriptCodegen(src *SourceFile, path string, templ *template.Template) {
+	out, err := os.Create(path)
+	if err != nil {
+		panic(fmt.Errorf("failed to create TypeScript file [%s]: %s", path, err.Error()))
+	}
+	defer out.Close()
+	if err := templ.Execute(out, buildTypeScript(src)); err != nil {
+		panic(fmt.Errorf("failed to process template [%s]: %s", templ.Name(), err.Error()))
+	}
+}
+
+func buildTypeScript(src *SourceFile) *TypeScriptCodegen {
+	b := &tsBuilder{src: src, interfaces: make(map[string]*TsInterface)}
+	result := &TypeScriptCodegen{}
+	receivers := make([]string, 0, len(src.Methods))
+	for receiver := range src.Methods {
+		receivers = append(receivers, receiver)
+	}
+	sort.Strings(receivers)
+	for _, receiver := range receivers {
+		client := &TsClient{Receiver: receiver}
+		for _, api := range src.Methods[receiver] {
+			httpMethod := api.Method
+			if httpMethod == "" {
+				httpMethod = "GET"
+			}
+			name := api.Target.Name.Name
+			client.Methods = append(client.Methods, TsMethod{
+				Name:       string(unicode.ToLower(rune(name[0]))) + name[1:],
+				Url:        api.Url,
+				HttpMethod: httpMethod,
+				Params:     b.paramsInterface(typeName(api.ArgType)),
+				Result:     b.tsType(api.ResultType),
+				AuthToken:  api.Auth.Token,
+				ApiKey:     api.Auth.UseApiKey,
+			})
+		}
+		result.Clients = append(result.Clients, client)
+	}
+	names := make([]string, 0, len(b.interfaces))
+	for name := range b.interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result.Interfaces = append(result.Interfaces, b.interfaces[name])
+	}
+	return result
+}
+
+// Interface of request params by their param names. Enumerated params are unions of literals.
+func (b *tsBuilder) paramsInterface(structName string) string {
+	params := b.src.ValidatorOf(structName)
+	if params == nil {
+		return "Record<string, never>"
+	}
+	if _, collected := b.interfaces[structName]; collected {
+		return structName
+	}
+	iface := &TsInterface{Name: structName}
+	for _, fieldName := range params.FieldNames() {
+		validator := params.Validators[fieldName]
+		tsType := "string"
+		if validator.FieldType == IntFieldType {
+			tsType = "number"
+		} else if validator.HasEnumConstraint() {
+			literals := make([]string, len(validator.Enum))
+			for i, value := range validator.Enum {
+				literals[i] = strconv.Quote(value)
+			}
+			tsType = strings.Join(literals, " | ")
+		}
+		iface.Fields = append(iface.Fields, TsField{Name: tsFieldName(validator.ParamName), Type: tsType, Optional: !isRequiredParam(validator)})
+	}
+	b.interfaces[structName] = iface
+	return structName
+}
+
+// TypeScript type of Go type. Structs declared in source file become interfaces by their `json` tags.
+func (b *tsBuilder) tsType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return b.tsType(t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return "string" // base64 encoded
+		}
+		return "Array<" + b.tsType(t.Elt) + ">"
+	case *ast.MapType:
+		return "Record<string, " + b.tsType(t.Value) + ">"
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok && pkg.Name == "time" && t.Sel.Name == "Time" {
+			return "string"
+		}
+	case *ast.Ident:
+		if tsType, ok := tsBuiltinTypes[t.Name]; ok {
+			return tsType
+		}
+		if st, ok := b.src.Types[t.Name]; ok {
+			if _, collected := b.interfaces[t.Name]; !collected {
+				iface := &TsInterface{Name: t.Name}
+				b.interfaces[t.Name] = iface // collected before fields, so recursive types are referenced
+				iface.Fields = b.structFields(st)
+			}
+			return t.Name
+		}
+	}
+	return "unknown"
+}
+
+func (b *tsBuilder) structFields(st *ast.StructType) (fields []TsField) {
+	for _, field := range jsonFields(b.src.Types, st) {
+		tsType := b.tsType(field.Type)
+		if _, isPointer := field.Type.(*ast.StarExpr); isPointer {
+			tsType += " | null"
+		}
+		fields = append(fields, TsField{Name: tsFieldName(field.Name), Type: tsType, Optional: field.OmitEmpty})
+	}
+	return
+}
+
+func tsFieldName(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -245,6 +246,29 @@ func TestMyApi(t *testing.T) {
 				"error": "bad user",
 			},
 		},
+		Case{ // create a user with quotes in login
+			Path:   ApiUserCreate,
+			Method: http.MethodPost,
+			Query:  "login=\"quoted\"_moderator&age=32&full_name=Ivan_Ivanov",
+			Status: http.StatusOK,
+			Auth:   true,
+			Result: CR{
+				"error": "",
+				"response": CR{
+					"id": 45,
+				},
+			},
+		},
+		Case{ // user input in error message is properly escaped
+			Path:   ApiUserCreate,
+			Method: http.MethodPost,
+			Query:  "login=\"quoted\"_moderator&age=32&full_name=Ivan_Ivanov",
+			Status: http.StatusConflict,
+			Auth:   true,
+			Result: CR{
+				"error": "user \"quoted\"_moderator exist",
+			},
+		},
 	}
 
 	runTests(t, ts, cases)
@@ -280,6 +304,22 @@ func TestOtherApi(t *testing.T) {
 				},
 			},
 		},
+		Case{ // form body is url-decoded, the first of repeated params is used
+			Path:   ApiUserCreate,
+			Method: http.MethodPost,
+			Query:  "username=I3ap%20Bap&username=other&level=1&class=warrior&account_name=Vasily+Romanov%21",
+			Status: http.StatusOK,
+			Auth:   true,
+			Result: CR{
+				"error": "",
+				"response": CR{
+					"id":        12,
+					"login":     "I3ap Bap",
+					"full_name": "Vasily Romanov!",
+					"level":     1,
+				},
+			},
+		},
 	}
 
 	runTests(t, ts, cases)
@@ -329,6 +369,14 @@ func runTests(t *testing.T, ts *httptest.Server, cases []Case) {
 			continue
 		}
 
+		// errors carry id of request, which differs from one request to another
+		if fields, ok := result.(map[string]interface{}); ok && fields["request_id"] != nil {
+			if fields["request_id"] != resp.Header.Get("X-Request-ID") {
+				t.Errorf("[%s] expected request id of error to match header, got %v", caseName, fields["request_id"])
+			}
+			delete(fields, "request_id")
+		}
+
 		// reflect.DeepEqual does not work if we receive different types
 		// and there they come with different types (string VS interface{}) compared to what is in the expected result
 		// this dirty little hack converts data first to json and then back to interface - getting compatible results
@@ -342,3 +390,125 @@ func runTests(t *testing.T, ts *httptest.Server, cases []Case) {
 		}
 	}
 }
+
+func TestMyApiClient(t *testing.T) {
+	ts := httptest.NewServer(NewMyApi())
+	defer ts.Close()
+	ctx := context.Background()
+	client := NewMyApiClient(ts.URL)
+
+	user, err := client.Profile(ctx, ProfileParams{Login: "rvasily"})
+	if err != nil || user.ID != 42 || user.FullName != "Vasily Romanov" {
+		t.Fatalf("unexpected profile: %#v, %v", user, err)
+	}
+
+	_, err = client.Create(ctx, CreateParams{Login: "client_moderator", Age: 32, Name: "Client"})
+	if apiErr, ok := err.(ApiError); !ok || apiErr.HTTPStatus != http.StatusForbidden || apiErr.Error() != "unauthorized" {
+		t.Fatalf("expected unauthorized ApiError, got %#v", err)
+	}
+
+	client.AuthToken = "100500"
+	newUser, err := client.Create(ctx, CreateParams{Login: "client_moderator", Age: 32, Name: "Client"})
+	if err != nil || newUser.ID != 43 {
+		t.Fatalf("unexpected new user: %#v, %v", newUser, err)
+	}
+
+	_, err = client.Create(ctx, CreateParams{Login: "client_moderator", Age: 32, Name: "Client"})
+	if apiErr, ok := err.(ApiError); !ok || apiErr.HTTPStatus != http.StatusConflict || apiErr.Error() != "user client_moderator exist" {
+		t.Fatalf("expected conflict ApiError, got %#v", err)
+	}
+
+	_, err = client.Create(ctx, CreateParams{Login: "short", Age: 32})
+	if apiErr, ok := err.(ApiError); !ok || apiErr.HTTPStatus != http.StatusBadRequest || apiErr.Error() != "login len must be >= 10" {
+		t.Fatalf("expected bad request ApiError, got %#v", err)
+	}
+
+	user, err = client.Profile(ctx, ProfileParams{Login: "client_moderator"})
+	if err != nil || user.FullName != "Client" || user.Status != statusUser {
+		t.Fatalf("unexpected profile: %#v, %v", user, err)
+	}
+}
+
+// Value of metric sample scraped from metrics handler. Missing sample is zero.
+func scrapeMetric(t *testing.T, sample string) float64 {
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, sample+" ") {
+			var value float64
+			if _, err := fmt.Sscan(strings.TrimPrefix(line, sample+" "), &value); err != nil {
+				t.Fatalf("bad sample %q: %v", line, err)
+			}
+			return value
+		}
+	}
+	return 0
+}
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(NewMyApi())
+	defer ts.Close()
+
+	const (
+		okProfiles  = `api_requests_total{receiver="MyApi",method="Profile",code="2xx"}`
+		badProfiles = `api_requests_total{receiver="MyApi",method="Profile",code="4xx"}`
+		failedLogin = `api_validation_failures_total{receiver="MyApi",method="Profile",param="login"}`
+		latencyAll  = `api_request_duration_seconds_bucket{receiver="MyApi",method="Profile",le="+Inf"}`
+		inFlight    = `api_requests_in_flight{receiver="MyApi",method="Profile"}`
+	)
+	before := map[string]float64{}
+	for _, sample := range []string{okProfiles, badProfiles, failedLogin, latencyAll} {
+		before[sample] = scrapeMetric(t, sample)
+	}
+
+	for _, query := range []string{"login=rvasily", "login=rvasily", "login="} {
+		resp, err := client.Get(ts.URL + ApiUserProfile + "?" + query)
+		if err != nil {
+			t.Fatalf("request error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	for sample, delta := range map[string]float64{okProfiles: 2, badProfiles: 1, failedLogin: 1, latencyAll: 3} {
+		if got := scrapeMetric(t, sample) - before[sample]; got != delta {
+			t.Errorf("expected %s to grow by %v, got %v", sample, delta, got)
+		}
+	}
+	if got := scrapeMetric(t, inFlight); got != 0 {
+		t.Errorf("expected no requests in flight, got %v", got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ts := httptest.NewServer(NewMyApi())
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+ApiUserProfile+"?login=rvasily", nil)
+	req.Header.Set("X-Request-ID", "client-request-1")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got != "client-request-1" {
+		t.Errorf("expected request id of client to be echoed, got %q", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+"/user/unknown", nil)
+	req.Header.Set("X-Request-ID", strings.Repeat("too long", 20))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	var body struct {
+		RequestID string `json:"request_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); len(got) != 32 {
+		t.Errorf("expected created request id, got %q", got)
+	}
+	if body.RequestID != resp.Header.Get("X-Request-ID") {
+		t.Errorf("expected request id in error of legacy envelope, got %q", body.RequestID)
+	}
+}